@@ -2,13 +2,132 @@ package hooks
 
 import "github.com/tomasz-wiszkowski/git-hooks/config"
 
+// Outcome of a single Action run.
+type RunResult int8
+
+const (
+	// Action ran and completed successfully.
+	RunSucceeded RunResult = iota
+	// Action ran and reported a failure.
+	RunFailed
+	// Action did not run, eg. because it was not selected or no files matched.
+	RunSkipped
+	// Action was selected, but could not be run, eg. because the command is missing.
+	RunUnavailable
+	// Action did not complete within the permitted time.
+	RunTimedOut
+)
+
+// Return the human-readable representation of the result.
+func (r RunResult) String() string {
+	switch r {
+	case RunSucceeded:
+		return "ok"
+	case RunFailed:
+		return "failed"
+	case RunSkipped:
+		return "skipped"
+	case RunUnavailable:
+		return "unavailable"
+	case RunTimedOut:
+		return "timed out"
+	}
+	return "unknown"
+}
+
+// Return whether the result should be treated as failure of the action.
+func (r RunResult) IsFailure() bool {
+	return r == RunFailed || r == RunTimedOut
+}
+
+// Policy applied when an Action fails.
+type FailurePolicy int8
+
+const (
+	// Failure of the action rejects the git operation.
+	FailureBlocks FailurePolicy = iota
+	// Failure of the action is reported, but does not reject the git operation.
+	FailureWarns
+	// Failure of the action is ignored.
+	FailureIgnored
+)
+
+const (
+	configOnFailureBlock  = "block"
+	configOnFailureWarn   = "warn"
+	configOnFailureIgnore = "ignore"
+)
+
+// Translate the configuration value into a FailurePolicy.
+// Empty value is interpreted as FailureBlocks. Returns false if the value is
+// not recognized.
+func parseFailurePolicy(value string) (FailurePolicy, bool) {
+	switch value {
+	case "", configOnFailureBlock:
+		return FailureBlocks, true
+	case configOnFailureWarn:
+		return FailureWarns, true
+	case configOnFailureIgnore:
+		return FailureIgnored, true
+	}
+	return FailureBlocks, false
+}
+
+// Return the configuration representation of the policy.
+func (p FailurePolicy) String() string {
+	switch p {
+	case FailureBlocks:
+		return configOnFailureBlock
+	case FailureWarns:
+		return configOnFailureWarn
+	case FailureIgnored:
+		return configOnFailureIgnore
+	}
+	return "unknown"
+}
+
+const (
+	configSeverityError   = "error"
+	configSeverityWarning = "warning"
+	configSeverityInfo    = "info"
+)
+
+// Translate the severity of the action, as specified in the config file, into
+// a FailurePolicy: errors block, warnings warn, and information is ignored.
+// Empty value is interpreted as FailureBlocks. Returns false if the value is
+// not recognized.
+func parseSeverity(value string) (FailurePolicy, bool) {
+	switch value {
+	case "", configSeverityError:
+		return FailureBlocks, true
+	case configSeverityWarning:
+		return FailureWarns, true
+	case configSeverityInfo:
+		return FailureIgnored, true
+	}
+	return FailureBlocks, false
+}
+
 type Action interface {
 	ID() string
 	Name() string
 	Priority() int32
+	OnFailure() FailurePolicy
 	SetSelected(bool)
 	IsSelected() bool
 	IsAvailable() bool
 	SetConfig(config.Config)
-	Run(file []string, args []string)
+	Run(ctx *RunContext) RunResult
+}
+
+// Implemented by actions that may modify files in place, eg. formatters.
+type fileModifier interface {
+	// Return whether the action modifies files in place.
+	ModifiesFiles() bool
+}
+
+// Return whether the action may modify files in place.
+func modifiesFiles(a Action) bool {
+	m, ok := a.(fileModifier)
+	return ok && m.ModifiesFiles()
 }
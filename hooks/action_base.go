@@ -0,0 +1,109 @@
+package hooks
+
+import (
+	"log"
+
+	"github.com/tomasz-wiszkowski/git-hooks/check"
+	"github.com/tomasz-wiszkowski/git-hooks/config"
+)
+
+// actionBase implements the state and configuration shared by all actions.
+type actionBase struct {
+	// Unique ID of the hook. Not enforced.
+	id string
+	// Human-readable name of the hook.
+	name string
+	// Execution prioirty.
+	priority int32
+	// Policy applied when the hook fails.
+	onFailure FailurePolicy
+	// Whether the hook is selected to be run.
+	selected bool
+	// Related configuration section where additional metadata may be stored.
+	config config.Config
+}
+
+// Create a new actionBase object from the supplied pieces.
+func newActionBase(id, name string, priority int32, onFailure FailurePolicy) actionBase {
+	return actionBase{
+		id:        id,
+		name:      name,
+		priority:  priority,
+		onFailure: onFailure,
+		selected:  false,
+		config:    nil,
+	}
+}
+
+// builtinAction implements the state shared by built-in actions.
+type builtinAction struct {
+	actionBase
+}
+
+// Create a new builtinAction object from the supplied pieces.
+func newBuiltinAction(id, name string, priority int32, onFailure FailurePolicy) builtinAction {
+	return builtinAction{newActionBase(id, name, priority, onFailure)}
+}
+
+// Built-in actions require no external tools.
+func (a *builtinAction) IsAvailable() bool {
+	return true
+}
+
+// Return the unique ID of this hook.
+func (a *actionBase) ID() string {
+	return a.id
+}
+
+// Return the human readable name of the hook.
+func (a *actionBase) Name() string {
+	return a.name
+}
+
+// Return priority of the hook. Lower number = higher priority.
+func (a *actionBase) Priority() int32 {
+	return a.priority
+}
+
+// Return the policy applied when the hook fails.
+func (a *actionBase) OnFailure() FailurePolicy {
+	return a.onFailure
+}
+
+// Return whether the hook is requested to be run.
+func (a *actionBase) IsSelected() bool {
+	return a.selected
+}
+
+// Modify the selected state of the hook.
+func (a *actionBase) SetSelected(wantSelected bool) {
+	a.selected = wantSelected
+
+	if wantSelected {
+		a.config.Set(keyEnabled, valueTrue)
+	} else {
+		a.config.Remove(keyEnabled)
+	}
+}
+
+// Specify the configuration section responsible for managing the hook data.
+func (a *actionBase) SetConfig(cfg config.Config) {
+	a.config = cfg
+	check.True(cfg != nil, "No config section")
+
+	a.SetSelected(cfg.GetOrDefault(keyEnabled, "") == valueTrue)
+
+	// The severity takes the values of the config file. The onFailure key is
+	// its legacy form, taking version 1 values.
+	if severity := cfg.GetOrDefault(keySeverity, ""); len(severity) > 0 {
+		if onFailure, ok := parseSeverity(severity); ok {
+			a.onFailure = onFailure
+		} else {
+			log.Println("Ignoring invalid", keySeverity, "value for", a.Name())
+		}
+	} else if onFailure, ok := parseFailurePolicy(cfg.GetOrDefault(keyOnFailure, a.onFailure.String())); ok {
+		a.onFailure = onFailure
+	} else {
+		log.Println("Ignoring invalid", keyOnFailure, "value for", a.Name())
+	}
+}
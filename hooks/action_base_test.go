@@ -0,0 +1,32 @@
+package hooks
+
+import "testing"
+
+func Test_actionBase_SetConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		dflt   FailurePolicy
+		config fakeConfig
+		want   FailurePolicy
+	}{
+		{"Default kept", FailureWarns, fakeConfig{}, FailureWarns},
+		{"Override block", FailureIgnored, fakeConfig{keyOnFailure: "block"}, FailureBlocks},
+		{"Override warn", FailureBlocks, fakeConfig{keyOnFailure: "warn"}, FailureWarns},
+		{"Override ignore", FailureBlocks, fakeConfig{keyOnFailure: "ignore"}, FailureIgnored},
+		{"Invalid override ignored", FailureWarns, fakeConfig{keyOnFailure: "maybe"}, FailureWarns},
+		{"Severity error", FailureIgnored, fakeConfig{keySeverity: "error"}, FailureBlocks},
+		{"Severity warning", FailureBlocks, fakeConfig{keySeverity: "warning"}, FailureWarns},
+		{"Severity info", FailureBlocks, fakeConfig{keySeverity: "info"}, FailureIgnored},
+		{"Severity takes precedence", FailureBlocks, fakeConfig{keySeverity: "info", keyOnFailure: "warn"}, FailureIgnored},
+		{"Invalid severity ignored", FailureWarns, fakeConfig{keySeverity: "block"}, FailureWarns},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newActionBase("a", "a", 0, tt.dflt)
+			a.SetConfig(tt.config)
+			if got := a.OnFailure(); got != tt.want {
+				t.Errorf("OnFailure() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
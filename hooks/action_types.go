@@ -0,0 +1,58 @@
+package hooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+
+	"github.com/tomasz-wiszkowski/git-hooks/check"
+)
+
+// Creates an action of a specific type from its configuration.
+// Type-specific settings are available as raw JSON in cfg.Options.
+type actionFactory func(id string, cfg *actionConfig, onFailure FailurePolicy) (Action, error)
+
+// Registry of known action types, keyed by the value of the "type" field.
+var kActionTypes = map[string]actionFactory{}
+
+// Register a new action type. Every type must be registered only once,
+// typically from the init() function of the file implementing the action.
+func registerActionType(name string, factory actionFactory) {
+	_, exists := kActionTypes[name]
+	check.True(!exists, "Action type %s registered twice", name)
+	kActionTypes[name] = factory
+}
+
+// Register a new action type, created by the supplied constructor returning
+// a concrete action type.
+func registerActionConstructor[T Action](name string, constructor func(id string, cfg *actionConfig, onFailure FailurePolicy) (T, error)) {
+	registerActionType(name, func(id string, cfg *actionConfig, onFailure FailurePolicy) (Action, error) {
+		action, err := constructor(id, cfg, onFailure)
+		if err != nil {
+			// Don't return a nil pointer wrapped in a non-nil interface.
+			return nil, err
+		}
+		return action, nil
+	})
+}
+
+// Return the sorted list of known action types.
+func getActionTypes() []string {
+	types := []string{}
+	for name := range kActionTypes {
+		types = append(types, name)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Decode type-specific options into the target, rejecting unknown fields.
+// Empty options leave the target intact.
+func decodeOptions(options json.RawMessage, target interface{}) error {
+	if len(options) == 0 {
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(options))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(target)
+}
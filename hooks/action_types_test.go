@@ -0,0 +1,43 @@
+package hooks
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func Test_actionTypeFactories(t *testing.T) {
+	for _, name := range getActionTypes() {
+		t.Run(name, func(t *testing.T) {
+			cfg := &actionConfig{
+				Type:     name,
+				Name:     "Test",
+				RunType:  configRunTypePerFile,
+				ShellCmd: []string{"true"},
+			}
+			action, err := kActionTypes[name]("test", cfg, FailureWarns)
+			if err != nil {
+				t.Fatalf("factory error = %v", err)
+			}
+			if action.ID() != "test" || action.Name() != "Test" || action.OnFailure() != FailureWarns {
+				t.Errorf("factory did not retain common settings")
+			}
+		})
+	}
+}
+
+func Test_decodeOptions(t *testing.T) {
+	type options struct {
+		Value int `json:"value"`
+	}
+
+	opts := options{Value: 1}
+	if err := decodeOptions(nil, &opts); err != nil || opts.Value != 1 {
+		t.Errorf("empty options should retain defaults, got %v, %v", opts, err)
+	}
+	if err := decodeOptions(json.RawMessage(`{"value": 2}`), &opts); err != nil || opts.Value != 2 {
+		t.Errorf("options not decoded, got %v, %v", opts, err)
+	}
+	if err := decodeOptions(json.RawMessage(`{"valeu": 3}`), &opts); err == nil {
+		t.Errorf("unknown option not rejected")
+	}
+}
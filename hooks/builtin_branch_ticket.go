@@ -0,0 +1,143 @@
+package hooks
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Options of the branch ticket prefix action.
+type branchTicketOptions struct {
+	// Regular expression extracting the ticket ID from the branch name. If
+	// the expression contains a capturing group, the first group is used.
+	Pattern string `json:"pattern"`
+	// Format of the prefix, where %s is replaced with the ticket ID.
+	Format string `json:"format"`
+}
+
+// Prefixes commit messages with the ticket ID extracted from the branch name.
+type branchTicketAction struct {
+	builtinAction
+	pattern *regexp.Regexp
+	format  string
+}
+
+func init() {
+	registerActionConstructor("builtin:branch-ticket-prefix", newBranchTicketAction)
+}
+
+// Create a new branchTicketAction from the supplied configuration. Options
+// may be empty, in which case defaults are used.
+func newBranchTicketAction(id string, cfg *actionConfig, onFailure FailurePolicy) (*branchTicketAction, error) {
+	opts := branchTicketOptions{
+		Pattern: `[A-Z][A-Z0-9]+-[0-9]+`,
+		Format:  "%s: ",
+	}
+
+	if err := decodeOptions(cfg.Options, &opts); err != nil {
+		return nil, err
+	}
+
+	pattern, err := regexp.Compile(opts.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", opts.Pattern, err)
+	}
+	if strings.Count(opts.Format, "%s") != 1 {
+		return nil, fmt.Errorf("format %q must contain exactly one %%s", opts.Format)
+	}
+
+	return &branchTicketAction{
+		builtinAction: newBuiltinAction(id, cfg.Name, cfg.Priority, onFailure),
+		pattern:       pattern,
+		format:        opts.Format,
+	}, nil
+}
+
+// Insert the ticket ID into the commit message file supplied by git.
+// Messages of merge, squash and amended commits are left intact, as are
+// messages already referencing the ticket.
+func (a *branchTicketAction) Run(ctx *RunContext) RunResult {
+	if !a.IsSelected() {
+		return RunSkipped
+	}
+
+	msgFile, ok := getHookArg(ctx.HookID, ctx.Args, argMsgFile)
+	if !ok {
+		fmt.Println("Cannot run", a.Name(), "- hook", ctx.HookID, "does not supply commit message")
+		return RunUnavailable
+	}
+
+	switch source, _ := getHookArg(ctx.HookID, ctx.Args, argCommitSource); source {
+	case "merge", "squash", "commit":
+		return RunSkipped
+	}
+
+	branch, err := ctx.Repo.CurrentBranch()
+	if err != nil {
+		return RunSkipped
+	}
+
+	ticket := a.extractTicket(branch)
+	if len(ticket) == 0 {
+		return RunSkipped
+	}
+
+	content, err := os.ReadFile(msgFile)
+	if err != nil {
+		fmt.Println("Cannot run", a.Name(), "-", err)
+		return RunFailed
+	}
+
+	message, changed := insertTicketPrefix(string(content), ticket, a.format)
+	if !changed {
+		return RunSucceeded
+	}
+
+	if err = os.WriteFile(msgFile, []byte(message), 0644); err != nil {
+		fmt.Println("Cannot run", a.Name(), "-", err)
+		return RunFailed
+	}
+	return RunSucceeded
+}
+
+// Extract the ticket ID from the branch name.
+// Returns an empty string if the branch does not reference any ticket.
+func (a *branchTicketAction) extractTicket(branch string) string {
+	match := a.pattern.FindStringSubmatch(branch)
+	if match == nil {
+		return ""
+	}
+	if len(match) > 1 {
+		return match[1]
+	}
+	return match[0]
+}
+
+// Prefix the first line of the commit message that is not a comment with the
+// formatted ticket ID. Returns the resulting message, and whether it was
+// modified. Messages already referencing the ticket are not modified.
+func insertTicketPrefix(message, ticket, format string) (string, bool) {
+	lines := strings.Split(message, "\n")
+
+	first := -1
+	for i, line := range lines {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.Contains(line, ticket) {
+			return message, false
+		}
+		if first < 0 {
+			first = i
+		}
+	}
+
+	prefix := fmt.Sprintf(format, ticket)
+	if first < 0 {
+		return prefix + "\n" + message, true
+	}
+
+	lines[first] = prefix + lines[first]
+	return strings.Join(lines, "\n"), true
+}
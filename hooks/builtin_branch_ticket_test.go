@@ -0,0 +1,81 @@
+package hooks
+
+import "testing"
+
+func Test_insertTicketPrefix(t *testing.T) {
+	tests := []struct {
+		name        string
+		message     string
+		want        string
+		wantChanged bool
+	}{
+		{
+			name:        "Message supplied with -m",
+			message:     "Fix parser\n",
+			want:        "PROJ-12: Fix parser\n",
+			wantChanged: true,
+		},
+		{
+			name:        "Empty message from editor template",
+			message:     "\n# Please enter the commit message.\n",
+			want:        "PROJ-12: \n# Please enter the commit message.\n",
+			wantChanged: true,
+		},
+		{
+			name:        "Message starting with comments",
+			message:     "# Comment\nFix parser\n",
+			want:        "# Comment\nPROJ-12: Fix parser\n",
+			wantChanged: true,
+		},
+		{
+			name:        "Comments only",
+			message:     "# Comment",
+			want:        "PROJ-12: \n# Comment",
+			wantChanged: true,
+		},
+		{
+			name:        "Ticket already present",
+			message:     "Fix parser\n\nRefs: PROJ-12\n",
+			want:        "Fix parser\n\nRefs: PROJ-12\n",
+			wantChanged: false,
+		},
+		{
+			name:        "Ticket mentioned only in comments",
+			message:     "Fix parser\n# On branch feature/PROJ-12-parser\n",
+			want:        "PROJ-12: Fix parser\n# On branch feature/PROJ-12-parser\n",
+			wantChanged: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed := insertTicketPrefix(tt.message, "PROJ-12", "%s: ")
+			if got != tt.want || changed != tt.wantChanged {
+				t.Errorf("insertTicketPrefix() = %q, %v, want %q, %v", got, changed, tt.want, tt.wantChanged)
+			}
+		})
+	}
+}
+
+func Test_branchTicketExtract(t *testing.T) {
+	tests := []struct {
+		name    string
+		options string
+		branch  string
+		want    string
+	}{
+		{"Default pattern", "", "feature/PROJ-1234-description", "PROJ-1234"},
+		{"No ticket", "", "main", ""},
+		{"Capturing group", `{"pattern": "^[a-z]+/([a-z]+-[0-9]+)"}`, "bugfix/abc-12-crash", "abc-12"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := newBranchTicketAction("id", &actionConfig{Name: "name", Options: []byte(tt.options)}, FailureBlocks)
+			if err != nil {
+				t.Fatalf("newBranchTicketAction() error = %v", err)
+			}
+			if got := a.extractTicket(tt.branch); got != tt.want {
+				t.Errorf("extractTicket() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
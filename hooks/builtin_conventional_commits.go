@@ -0,0 +1,197 @@
+package hooks
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Default list of commit types, following the Angular convention.
+var kConventionalCommitTypes = []string{
+	"build", "chore", "ci", "docs", "feat", "fix", "perf", "refactor", "revert", "style", "test",
+}
+
+var (
+	// Matches the header line, eg. "feat(parser)!: add support for arrays".
+	kConventionalHeader = regexp.MustCompile(`^([a-zA-Z]+)(?:\(([^()]*)\))?(!)?: (.*)$`)
+	// Matches the first line of a footer, eg. "Refs: #123" or "BREAKING CHANGE: ...".
+	kConventionalTrailer = regexp.MustCompile(`^(BREAKING CHANGE|[A-Za-z0-9-]+)(: | #)\S`)
+	// Marks the line below which git discards the message content.
+	kScissorsLine = "# ------------------------ >8 ------------------------"
+)
+
+// Options of the Conventional Commits validator.
+type conventionalCommitsOptions struct {
+	// Permitted commit types.
+	Types []string `json:"types"`
+	// Permitted scopes. Any scope is permitted if empty.
+	Scopes []string `json:"scopes"`
+	// Whether every commit must specify the scope.
+	RequireScope bool `json:"requireScope"`
+	// Maximum length of the subject. Zero means no limit.
+	MaxSubjectLength int `json:"maxSubjectLength"`
+}
+
+// Validates commit messages against the Conventional Commits specification
+// (https://www.conventionalcommits.org).
+type conventionalCommitsAction struct {
+	builtinAction
+	options conventionalCommitsOptions
+}
+
+func init() {
+	registerActionConstructor("builtin:conventional-commits", newConventionalCommitsAction)
+}
+
+// Create a new conventionalCommitsAction from the supplied configuration.
+// Options may be empty, in which case defaults are used.
+func newConventionalCommitsAction(id string, cfg *actionConfig, onFailure FailurePolicy) (*conventionalCommitsAction, error) {
+	a := &conventionalCommitsAction{
+		builtinAction: newBuiltinAction(id, cfg.Name, cfg.Priority, onFailure),
+		options: conventionalCommitsOptions{
+			Types:            kConventionalCommitTypes,
+			MaxSubjectLength: 72,
+		},
+	}
+
+	if err := decodeOptions(cfg.Options, &a.options); err != nil {
+		return nil, err
+	}
+	if len(a.options.Types) == 0 {
+		return nil, fmt.Errorf("no commit types permitted")
+	}
+	if a.options.MaxSubjectLength < 0 {
+		return nil, fmt.Errorf("invalid maxSubjectLength %d", a.options.MaxSubjectLength)
+	}
+
+	return a, nil
+}
+
+// Validate the commit message file supplied by git.
+func (a *conventionalCommitsAction) Run(ctx *RunContext) RunResult {
+	if !a.IsSelected() {
+		return RunSkipped
+	}
+
+	msgFile, ok := getHookArg(ctx.HookID, ctx.Args, argMsgFile)
+	if !ok {
+		fmt.Println("Cannot run", a.Name(), "- hook", ctx.HookID, "does not supply commit message")
+		return RunUnavailable
+	}
+
+	content, err := os.ReadFile(msgFile)
+	if err != nil {
+		fmt.Println("Cannot run", a.Name(), "-", err)
+		return RunFailed
+	}
+
+	violations := validateConventionalCommit(string(content), &a.options)
+	for _, v := range violations {
+		fmt.Printf("%s: %s\n", a.Name(), v)
+	}
+	if len(violations) > 0 {
+		return RunFailed
+	}
+	return RunSucceeded
+}
+
+// Strip comments and the content discarded by git from the commit message.
+// Returns the remaining lines, without trailing empty lines.
+func cleanCommitMessage(message string) []string {
+	lines := []string{}
+	for _, line := range strings.Split(strings.ReplaceAll(message, "\r\n", "\n"), "\n") {
+		if line == kScissorsLine {
+			break
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, strings.TrimRight(line, " \t"))
+	}
+
+	for len(lines) > 0 && len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// Check the commit message against the Conventional Commits rules.
+// Returns the list of violated rules, or an empty list if the message is valid.
+// Messages generated by git for merges, reverts and autosquash are accepted.
+func validateConventionalCommit(message string, options *conventionalCommitsOptions) []string {
+	lines := cleanCommitMessage(message)
+	if len(lines) == 0 || len(lines[0]) == 0 {
+		return []string{"header: commit message must start with a header line"}
+	}
+
+	header := lines[0]
+	for _, prefix := range []string{"Merge ", "Revert \"", "fixup! ", "squash! ", "amend! "} {
+		if strings.HasPrefix(header, prefix) {
+			return []string{}
+		}
+	}
+
+	violations := []string{}
+	match := kConventionalHeader.FindStringSubmatch(header)
+	if match == nil {
+		violations = append(violations, fmt.Sprintf("header-format: header %q does not follow \"type(scope)!: subject\" format", header))
+	} else {
+		kind, scope, subject := match[1], match[2], match[4]
+
+		if !containsString(options.Types, kind) {
+			violations = append(violations, fmt.Sprintf("type: %q is not one of: %s", kind, strings.Join(options.Types, ", ")))
+		}
+		if len(scope) == 0 && options.RequireScope {
+			violations = append(violations, "scope: scope is required")
+		}
+		if len(scope) > 0 && len(options.Scopes) > 0 && !containsString(options.Scopes, scope) {
+			violations = append(violations, fmt.Sprintf("scope: %q is not one of: %s", scope, strings.Join(options.Scopes, ", ")))
+		}
+		if len(strings.TrimSpace(subject)) == 0 {
+			violations = append(violations, "subject: subject must not be empty")
+		}
+		if length := len([]rune(subject)); options.MaxSubjectLength > 0 && length > options.MaxSubjectLength {
+			violations = append(violations, fmt.Sprintf("subject-length: subject is %d characters long, limit is %d", length, options.MaxSubjectLength))
+		}
+	}
+
+	if len(lines) > 1 && len(lines[1]) > 0 {
+		violations = append(violations, "body-leading-blank: header must be followed by a blank line")
+	}
+
+	violations = append(violations, validateConventionalFooter(lines[1:])...)
+	return violations
+}
+
+// Check the footer of the commit message, ie. the last paragraph, if it
+// starts with a trailer. Every line of such paragraph must be either a trailer,
+// or a continuation of the preceding trailer.
+func validateConventionalFooter(lines []string) []string {
+	start := len(lines)
+	for start > 0 && len(lines[start-1]) > 0 {
+		start--
+	}
+	// Footer must be separated from the header with a blank line.
+	if start == 0 || start == len(lines) || !kConventionalTrailer.MatchString(lines[start]) {
+		return []string{}
+	}
+
+	violations := []string{}
+	for _, line := range lines[start+1:] {
+		if !kConventionalTrailer.MatchString(line) && !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
+			violations = append(violations, fmt.Sprintf("footer: %q is not a valid trailer", line))
+		}
+	}
+	return violations
+}
+
+// Check whether the list contains the value.
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
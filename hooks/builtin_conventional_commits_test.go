@@ -0,0 +1,108 @@
+package hooks
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_validateConventionalCommit(t *testing.T) {
+	defaults := conventionalCommitsOptions{
+		Types:            kConventionalCommitTypes,
+		MaxSubjectLength: 30,
+	}
+	scoped := conventionalCommitsOptions{
+		Types:        []string{"feat", "fix"},
+		Scopes:       []string{"api", "ui"},
+		RequireScope: true,
+	}
+
+	tests := []struct {
+		name    string
+		message string
+		options *conventionalCommitsOptions
+		want    []string
+	}{
+		{
+			name:    "Valid header",
+			message: "feat: add arrays\n",
+			options: &defaults,
+			want:    []string{},
+		},
+		{
+			name:    "Valid header with scope, breaking change, body and footer",
+			message: "feat(parser)!: add arrays\n\nLong description.\n\nBREAKING CHANGE: arrays\n  are now parsed\nRefs #123\n",
+			options: &defaults,
+			want:    []string{},
+		},
+		{
+			name:    "Comments and scissors are ignored",
+			message: "# Comment\nfix: typo\n\n# Another comment\n" + kScissorsLine + "\nnot: part of message\n",
+			options: &defaults,
+			want:    []string{},
+		},
+		{
+			name:    "Messages generated by git are accepted",
+			message: "Merge branch 'main' into feature\n",
+			options: &scoped,
+			want:    []string{},
+		},
+		{
+			name:    "Empty message",
+			message: "# Comment only\n\n",
+			options: &defaults,
+			want:    []string{"header: commit message must start with a header line"},
+		},
+		{
+			name:    "Malformed header",
+			message: "Added arrays\n",
+			options: &defaults,
+			want:    []string{`header-format: header "Added arrays" does not follow "type(scope)!: subject" format`},
+		},
+		{
+			name:    "Unknown type and long subject",
+			message: "feature: add support for arrays of structures\n",
+			options: &defaults,
+			want: []string{
+				`type: "feature" is not one of: build, chore, ci, docs, feat, fix, perf, refactor, revert, style, test`,
+				"subject-length: subject is 36 characters long, limit is 30",
+			},
+		},
+		{
+			name:    "Long header with short subject",
+			message: "feat(configuration): add support for arrays\n",
+			options: &defaults,
+			want:    []string{},
+		},
+		{
+			name:    "Missing scope",
+			message: "feat: add arrays\n",
+			options: &scoped,
+			want:    []string{"scope: scope is required"},
+		},
+		{
+			name:    "Unknown scope",
+			message: "feat(db): add arrays\n",
+			options: &scoped,
+			want:    []string{`scope: "db" is not one of: api, ui`},
+		},
+		{
+			name:    "Missing blank line",
+			message: "fix: typo\nLong description.\n",
+			options: &defaults,
+			want:    []string{"body-leading-blank: header must be followed by a blank line"},
+		},
+		{
+			name:    "Malformed footer",
+			message: "fix: typo\n\nDescription.\n\nRefs: #123\nReviewed by: someone\n",
+			options: &defaults,
+			want:    []string{`footer: "Reviewed by: someone" is not a valid trailer`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validateConventionalCommit(tt.message, tt.options); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("validateConventionalCommit() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,344 @@
+package hooks
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/tomasz-wiszkowski/git-hooks/config"
+)
+
+// Maximum number of problems reported for a single file.
+const maxReportedProblems = 10
+
+// Number of leading bytes inspected to determine whether file is binary.
+const binarySniffLength = 8000
+
+// UTF-8 encoded byte order mark.
+var kUtf8Bom = []byte{0xEF, 0xBB, 0xBF}
+
+// A single problem found in a file.
+type fileProblem struct {
+	// Line number, starting at 1. Zero if the problem concerns the whole file.
+	line int
+	// Description of the problem.
+	message string
+}
+
+// Inspects, and optionally fixes, the content of individual files.
+type fileCheck struct {
+	// Return the list of problems found in the content.
+	inspect func(content []byte) []fileProblem
+	// Return the content with all problems fixed. Nil if the problems can't
+	// be fixed automatically.
+	fix func(content []byte) []byte
+}
+
+// Options of the file checks.
+type fileCheckOptions struct {
+	// Whether problems should be fixed automatically.
+	Autofix bool `json:"autofix"`
+	// Expected line ending, either "lf" or "crlf". Used by line-endings check only.
+	Eol string `json:"eol"`
+}
+
+// Runs a fileCheck against every matching file.
+type fileCheckAction struct {
+	builtinAction
+	check   fileCheck
+	filter  *fileFilter
+	autofix bool
+	// Policy applied to automatically fixed files.
+	onFix FixPolicy
+}
+
+func init() {
+	registerFileCheck("builtin:trailing-whitespace", func(*fileCheckOptions) (fileCheck, error) {
+		return fileCheck{inspectTrailingWhitespace, fixTrailingWhitespace}, nil
+	})
+	registerFileCheck("builtin:end-of-file", func(*fileCheckOptions) (fileCheck, error) {
+		return fileCheck{inspectEndOfFile, fixEndOfFile}, nil
+	})
+	registerFileCheck("builtin:merge-conflict", func(*fileCheckOptions) (fileCheck, error) {
+		return fileCheck{inspectMergeConflict, nil}, nil
+	})
+	registerFileCheck("builtin:byte-order-mark", func(*fileCheckOptions) (fileCheck, error) {
+		return fileCheck{inspectByteOrderMark, fixByteOrderMark}, nil
+	})
+	registerFileCheck("builtin:line-endings", func(opts *fileCheckOptions) (fileCheck, error) {
+		switch opts.Eol {
+		case "", "lf":
+			return fileCheck{
+				func(content []byte) []fileProblem { return inspectLineEndings(content, false) },
+				func(content []byte) []byte { return fixLineEndings(content, false) },
+			}, nil
+		case "crlf":
+			return fileCheck{
+				func(content []byte) []fileProblem { return inspectLineEndings(content, true) },
+				func(content []byte) []byte { return fixLineEndings(content, true) },
+			}, nil
+		}
+		return fileCheck{}, fmt.Errorf("invalid eol %q, expected lf or crlf", opts.Eol)
+	})
+}
+
+// Register an action type running the file check created by newCheck.
+func registerFileCheck(name string, newCheck func(*fileCheckOptions) (fileCheck, error)) {
+	registerActionType(name, func(id string, cfg *actionConfig, onFailure FailurePolicy) (Action, error) {
+		var opts fileCheckOptions
+		if err := decodeOptions(cfg.Options, &opts); err != nil {
+			return nil, err
+		}
+
+		check, err := newCheck(&opts)
+		if err != nil {
+			return nil, err
+		}
+		if opts.Autofix && check.fix == nil {
+			return nil, fmt.Errorf("%s does not support autofix", name)
+		}
+
+		filter, err := newFileFilter(cfg.Pattern, cfg.Include, cfg.Exclude)
+		if err != nil {
+			return nil, err
+		}
+
+		onFix, ok := parseFixPolicy(cfg.OnFix)
+		if !ok {
+			return nil, fmt.Errorf("invalid onFix %s", cfg.OnFix)
+		}
+
+		return &fileCheckAction{
+			builtinAction: newBuiltinAction(id, cfg.Name, cfg.Priority, onFailure),
+			check:         check,
+			filter:        filter,
+			autofix:       opts.Autofix,
+			onFix:         onFix,
+		}, nil
+	})
+}
+
+// Return whether the action fixes problems in place.
+func (a *fileCheckAction) ModifiesFiles() bool {
+	return a.autofix
+}
+
+// Specify the configuration section responsible for managing the hook data.
+func (a *fileCheckAction) SetConfig(cfg config.Config) {
+	a.actionBase.SetConfig(cfg)
+
+	if onFix, ok := parseFixPolicy(cfg.GetOrDefault(keyOnFix, a.onFix.String())); ok {
+		a.onFix = onFix
+	} else {
+		log.Println("Ignoring invalid", keyOnFix, "value for", a.Name())
+	}
+}
+
+// Inspect every matching text file, reporting problems. If autofix is
+// enabled, problems are fixed, and the fixed files are subject to the onFix
+// policy.
+func (a *fileCheckAction) Run(ctx *RunContext) RunResult {
+	if !a.IsSelected() {
+		return RunSkipped
+	}
+
+	matches := a.filter.Filter(ctx.Files)
+	if len(matches) == 0 {
+		return RunSkipped
+	}
+
+	snapshot := takeSnapshot(ctx, matches)
+	failed := false
+	fixed := false
+
+	for _, file := range matches {
+		content, ok := snapshot.content[file]
+		if !ok || isBinary(content) {
+			continue
+		}
+
+		problems := a.check.inspect(content)
+		if len(problems) == 0 {
+			continue
+		}
+
+		if !a.autofix {
+			reportProblems(a.Name(), file, problems)
+			failed = true
+			continue
+		}
+
+		if err := os.WriteFile(file, a.check.fix(content), 0644); err != nil {
+			log.Println(a.Name(), "failed to fix", file, "-", err)
+			failed = true
+			continue
+		}
+		fixed = true
+	}
+
+	// Files fixed before a failure are still staged, or reported.
+	result := RunSucceeded
+	if fixed {
+		result = applyFixes(ctx, a.Name(), a.onFix, snapshot)
+	}
+	if failed {
+		return RunFailed
+	}
+	return result
+}
+
+// Print problems found in the file, limiting their number.
+func reportProblems(name, file string, problems []fileProblem) {
+	for i, p := range problems {
+		if i == maxReportedProblems {
+			fmt.Printf("%s: %s: ... and %d more\n", name, file, len(problems)-i)
+			break
+		}
+		if p.line > 0 {
+			fmt.Printf("%s: %s:%d: %s\n", name, file, p.line, p.message)
+		} else {
+			fmt.Printf("%s: %s: %s\n", name, file, p.message)
+		}
+	}
+}
+
+// Check whether the content appears to be binary, ie. has NUL characters
+// among the leading bytes.
+func isBinary(content []byte) bool {
+	if len(content) > binarySniffLength {
+		content = content[:binarySniffLength]
+	}
+	return bytes.IndexByte(content, 0) >= 0
+}
+
+// Split the content into lines, retaining line terminators.
+func splitLinesKeepEnds(content []byte) [][]byte {
+	lines := [][]byte{}
+	for len(content) > 0 {
+		end := bytes.IndexByte(content, '\n')
+		if end < 0 {
+			end = len(content) - 1
+		}
+		lines = append(lines, content[:end+1])
+		content = content[end+1:]
+	}
+	return lines
+}
+
+// Split the line into its content and the terminator (LF, CRLF, or none).
+func splitLineEnding(line []byte) ([]byte, []byte) {
+	if bytes.HasSuffix(line, []byte("\r\n")) {
+		return line[:len(line)-2], line[len(line)-2:]
+	}
+	if bytes.HasSuffix(line, []byte("\n")) {
+		return line[:len(line)-1], line[len(line)-1:]
+	}
+	return line, nil
+}
+
+func inspectTrailingWhitespace(content []byte) []fileProblem {
+	problems := []fileProblem{}
+	for i, line := range splitLinesKeepEnds(content) {
+		text, _ := splitLineEnding(line)
+		if len(text) != len(bytes.TrimRight(text, " \t")) {
+			problems = append(problems, fileProblem{i + 1, "trailing whitespace"})
+		}
+	}
+	return problems
+}
+
+func fixTrailingWhitespace(content []byte) []byte {
+	var out bytes.Buffer
+	for _, line := range splitLinesKeepEnds(content) {
+		text, eol := splitLineEnding(line)
+		out.Write(bytes.TrimRight(text, " \t"))
+		out.Write(eol)
+	}
+	return out.Bytes()
+}
+
+func inspectEndOfFile(content []byte) []fileProblem {
+	if len(content) == 0 {
+		return []fileProblem{}
+	}
+	if content[len(content)-1] != '\n' {
+		return []fileProblem{{0, "missing newline at end of file"}}
+	}
+	if len(bytes.TrimRight(content, "\r\n")) == 0 || bytes.HasSuffix(bytes.ReplaceAll(content, []byte("\r\n"), []byte("\n")), []byte("\n\n")) {
+		return []fileProblem{{0, "extra empty lines at end of file"}}
+	}
+	return []fileProblem{}
+}
+
+func fixEndOfFile(content []byte) []byte {
+	trimmed := bytes.TrimRight(content, "\r\n")
+	if len(trimmed) == 0 {
+		return []byte{}
+	}
+
+	eol := []byte("\n")
+	if bytes.Contains(content, []byte("\r\n")) {
+		eol = []byte("\r\n")
+	}
+	return append(trimmed, eol...)
+}
+
+func inspectMergeConflict(content []byte) []fileProblem {
+	problems := []fileProblem{}
+	for i, line := range splitLinesKeepEnds(content) {
+		text, _ := splitLineEnding(line)
+		for _, marker := range []string{"<<<<<<<", ">>>>>>>"} {
+			if bytes.HasPrefix(text, []byte(marker)) && (len(text) == len(marker) || text[len(marker)] == ' ') {
+				problems = append(problems, fileProblem{i + 1, "merge conflict marker " + marker})
+			}
+		}
+	}
+	return problems
+}
+
+func inspectByteOrderMark(content []byte) []fileProblem {
+	if bytes.HasPrefix(content, kUtf8Bom) {
+		return []fileProblem{{1, "byte order mark"}}
+	}
+	return []fileProblem{}
+}
+
+func fixByteOrderMark(content []byte) []byte {
+	return bytes.TrimPrefix(content, kUtf8Bom)
+}
+
+// Report lines not terminated with the expected line ending.
+func inspectLineEndings(content []byte, crlf bool) []fileProblem {
+	problems := []fileProblem{}
+	for i, line := range splitLinesKeepEnds(content) {
+		_, eol := splitLineEnding(line)
+		if len(eol) == 0 {
+			continue
+		}
+		if crlf && len(eol) == 1 {
+			problems = append(problems, fileProblem{i + 1, "LF line ending, expected CRLF"})
+		} else if !crlf && len(eol) == 2 {
+			problems = append(problems, fileProblem{i + 1, "CRLF line ending, expected LF"})
+		}
+	}
+	return problems
+}
+
+// Terminate every line with the expected line ending.
+func fixLineEndings(content []byte, crlf bool) []byte {
+	var out bytes.Buffer
+	for _, line := range splitLinesKeepEnds(content) {
+		text, eol := splitLineEnding(line)
+		out.Write(text)
+		if len(eol) == 0 {
+			continue
+		}
+		if crlf {
+			out.WriteString("\r\n")
+		} else {
+			out.WriteString("\n")
+		}
+	}
+	return out.Bytes()
+}
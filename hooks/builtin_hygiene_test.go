@@ -0,0 +1,171 @@
+package hooks
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_fileChecks(t *testing.T) {
+	lf := fileCheck{
+		func(content []byte) []fileProblem { return inspectLineEndings(content, false) },
+		func(content []byte) []byte { return fixLineEndings(content, false) },
+	}
+	crlf := fileCheck{
+		func(content []byte) []fileProblem { return inspectLineEndings(content, true) },
+		func(content []byte) []byte { return fixLineEndings(content, true) },
+	}
+
+	tests := []struct {
+		name      string
+		check     fileCheck
+		content   string
+		wantLines []int
+		wantFixed string
+	}{
+		{
+			name:      "Trailing whitespace",
+			check:     fileCheck{inspectTrailingWhitespace, fixTrailingWhitespace},
+			content:   "a \nb\r\nc\t\r\nd  ",
+			wantLines: []int{1, 3, 4},
+			wantFixed: "a\nb\r\nc\r\nd",
+		},
+		{
+			name:      "No trailing whitespace",
+			check:     fileCheck{inspectTrailingWhitespace, fixTrailingWhitespace},
+			content:   "a\n\nb\n",
+			wantLines: []int{},
+			wantFixed: "a\n\nb\n",
+		},
+		{
+			name:      "Missing newline at end of file",
+			check:     fileCheck{inspectEndOfFile, fixEndOfFile},
+			content:   "a\nb",
+			wantLines: []int{0},
+			wantFixed: "a\nb\n",
+		},
+		{
+			name:      "Empty lines at end of file",
+			check:     fileCheck{inspectEndOfFile, fixEndOfFile},
+			content:   "a\r\nb\r\n\r\n",
+			wantLines: []int{0},
+			wantFixed: "a\r\nb\r\n",
+		},
+		{
+			name:      "Newlines only",
+			check:     fileCheck{inspectEndOfFile, fixEndOfFile},
+			content:   "\n\n",
+			wantLines: []int{0},
+			wantFixed: "",
+		},
+		{
+			name:      "Empty file",
+			check:     fileCheck{inspectEndOfFile, fixEndOfFile},
+			content:   "",
+			wantLines: []int{},
+			wantFixed: "",
+		},
+		{
+			name:      "Merge conflict markers",
+			check:     fileCheck{inspectMergeConflict, nil},
+			content:   "<<<<<<< HEAD\na\n=======\nb\n>>>>>>> branch\n<<<<<<<<\n",
+			wantLines: []int{1, 5},
+		},
+		{
+			name:      "Byte order mark",
+			check:     fileCheck{inspectByteOrderMark, fixByteOrderMark},
+			content:   "\xEF\xBB\xBFa\n",
+			wantLines: []int{1},
+			wantFixed: "a\n",
+		},
+		{
+			name:      "CRLF where LF expected",
+			check:     lf,
+			content:   "a\r\nb\nc\r\nd",
+			wantLines: []int{1, 3},
+			wantFixed: "a\nb\nc\nd",
+		},
+		{
+			name:      "LF where CRLF expected",
+			check:     crlf,
+			content:   "a\r\nb\nc\r\nd",
+			wantLines: []int{2},
+			wantFixed: "a\r\nb\r\nc\r\nd",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := []int{}
+			for _, p := range tt.check.inspect([]byte(tt.content)) {
+				lines = append(lines, p.line)
+			}
+			if !reflect.DeepEqual(lines, tt.wantLines) {
+				t.Errorf("inspect() lines = %v, want %v", lines, tt.wantLines)
+			}
+			if tt.check.fix == nil {
+				return
+			}
+			fixed := tt.check.fix([]byte(tt.content))
+			if string(fixed) != tt.wantFixed {
+				t.Errorf("fix() = %q, want %q", fixed, tt.wantFixed)
+			}
+			if problems := tt.check.inspect(fixed); len(problems) != 0 {
+				t.Errorf("inspect() after fix() = %v, want none", problems)
+			}
+		})
+	}
+}
+
+func Test_isBinary(t *testing.T) {
+	if isBinary([]byte("text\n")) {
+		t.Error("isBinary(text) = true")
+	}
+	if !isBinary([]byte("a\x00b")) {
+		t.Error("isBinary(binary) = false")
+	}
+}
+
+func Test_fileCheckAction_failedFix(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+	os.WriteFile(a, []byte("a \n"), 0644)
+	os.WriteFile(b, []byte("b \n"), 0644)
+
+	// Replacing b with a directory makes writing the fixed b fail.
+	check := fileCheck{inspectTrailingWhitespace, func(content []byte) []byte {
+		if string(content) == "b \n" {
+			os.Remove(b)
+			os.Mkdir(b, 0755)
+		}
+		return fixTrailingWhitespace(content)
+	}}
+	filter, err := newFileFilter("", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	action := &fileCheckAction{
+		builtinAction: newBuiltinAction("id", "name", 0, FailureBlocks),
+		check:         check,
+		filter:        filter,
+		autofix:       true,
+		onFix:         FixStages,
+	}
+	action.SetConfig(fakeConfig{keyEnabled: valueTrue})
+
+	r := &fakeRepo{}
+	ctx := &RunContext{HookID: "pre-commit", Repo: r, Files: []string{a, b}}
+	if got := action.Run(ctx); got != RunFailed {
+		t.Errorf("Run() = %v, want %v", got, RunFailed)
+	}
+	if content, _ := os.ReadFile(a); string(content) != "a\n" {
+		t.Errorf("a = %q, want %q", content, "a\n")
+	}
+	staged := false
+	for _, file := range r.staged {
+		staged = staged || file == a
+	}
+	if !staged {
+		t.Errorf("Run() staged %v, want the fixed %s", r.staged, a)
+	}
+}
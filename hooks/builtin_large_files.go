@@ -0,0 +1,227 @@
+package hooks
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/tomasz-wiszkowski/git-hooks/repo"
+)
+
+const (
+	// Default maximum size of a staged file.
+	defaultMaxFileSize = "500KB"
+
+	// Leading line of every Git LFS pointer file.
+	lfsPointerHeader = "version https://git-lfs.github.com/spec/v1"
+)
+
+// Options of the large files action.
+type largeFilesOptions struct {
+	// Maximum size of a staged file, eg. "500KB" or "10MB".
+	MaxSize string `json:"maxSize"`
+	// Whether binary files are accepted.
+	AllowBinary bool `json:"allowBinary"`
+	// Patterns of files exempt from all checks.
+	Allow []string `json:"allow"`
+	// Limits applied to selected files instead of the defaults.
+	Overrides []largeFilesOverride `json:"overrides"`
+}
+
+// Limits applied to files matching a path pattern.
+type largeFilesOverride struct {
+	// Pattern selecting files the override applies to.
+	Path string `json:"path"`
+	// Maximum size of a staged file. Empty means the default limit applies.
+	MaxSize string `json:"maxSize"`
+	// Whether binary files are accepted. Nil means the default policy applies.
+	AllowBinary *bool `json:"allowBinary"`
+	// Whether files must be stored with Git LFS.
+	Lfs bool `json:"lfs"`
+}
+
+// Limits applied to a single file.
+type fileLimits struct {
+	maxSize     int64
+	allowBinary bool
+	// Pattern the file must be tracked with by Git LFS. Empty if not required.
+	lfsPattern string
+}
+
+// Limits applied to files matching the pattern.
+type fileLimitsOverride struct {
+	match  func(string) bool
+	limits fileLimits
+}
+
+// Rejects staged files that are too large, or binary.
+type largeFilesAction struct {
+	builtinAction
+	filter    *fileFilter
+	allow     []func(string) bool
+	defaults  fileLimits
+	overrides []fileLimitsOverride
+}
+
+func init() {
+	registerActionConstructor("builtin:large-files", newLargeFilesAction)
+}
+
+// Create a new largeFilesAction from the supplied configuration.
+func newLargeFilesAction(id string, cfg *actionConfig, onFailure FailurePolicy) (*largeFilesAction, error) {
+	opts := largeFilesOptions{MaxSize: defaultMaxFileSize}
+	if err := decodeOptions(cfg.Options, &opts); err != nil {
+		return nil, err
+	}
+
+	filter, err := newFileFilter(cfg.Pattern, cfg.Include, cfg.Exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	maxSize, err := parseSize(opts.MaxSize)
+	if err != nil {
+		return nil, err
+	}
+
+	action := &largeFilesAction{
+		builtinAction: newBuiltinAction(id, cfg.Name, cfg.Priority, onFailure),
+		filter:        filter,
+		defaults:      fileLimits{maxSize: maxSize, allowBinary: opts.AllowBinary},
+	}
+
+	for _, pattern := range opts.Allow {
+		matcher, err := newPathMatcher(pattern)
+		if err != nil {
+			return nil, err
+		}
+		action.allow = append(action.allow, matcher)
+	}
+
+	for _, o := range opts.Overrides {
+		matcher, err := newPathMatcher(o.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		limits := action.defaults
+		if o.Lfs {
+			limits.lfsPattern = o.Path
+		}
+		if len(o.MaxSize) > 0 {
+			if limits.maxSize, err = parseSize(o.MaxSize); err != nil {
+				return nil, err
+			}
+		}
+		if o.AllowBinary != nil {
+			limits.allowBinary = *o.AllowBinary
+		}
+		action.overrides = append(action.overrides, fileLimitsOverride{matcher, limits})
+	}
+
+	return action, nil
+}
+
+// Inspect the staged content of every matching file, reporting files that
+// exceed the limits.
+func (a *largeFilesAction) Run(ctx *RunContext) RunResult {
+	if !a.IsSelected() {
+		return RunSkipped
+	}
+
+	matches := a.filter.Filter(ctx.Files)
+	if len(matches) == 0 {
+		return RunSkipped
+	}
+
+	blobs, err := ctx.Repo.GetStagedBlobs(matches, binarySniffLength)
+	if err != nil {
+		fmt.Println(a.Name(), "failed to inspect staged files:", err)
+		return RunFailed
+	}
+
+	result := RunSucceeded
+	for _, file := range matches {
+		blob, ok := blobs[file]
+		if !ok || matchAny(a.allow, file) {
+			continue
+		}
+
+		if problem := checkFileLimits(blob, a.limitsFor(file)); len(problem) > 0 {
+			fmt.Printf("%s: %s: %s\n", a.Name(), file, problem)
+			result = RunFailed
+		}
+	}
+	return result
+}
+
+// Return the limits applied to the file. The first matching override wins.
+func (a *largeFilesAction) limitsFor(file string) fileLimits {
+	for _, o := range a.overrides {
+		if o.match(file) {
+			return o.limits
+		}
+	}
+	return a.defaults
+}
+
+// Check the staged content against the limits. Returns the description of the
+// problem, or an empty string if the content is acceptable.
+func checkFileLimits(blob repo.StagedBlob, limits fileLimits) string {
+	isPointer := bytes.HasPrefix(blob.Head, []byte(lfsPointerHeader))
+	if len(limits.lfsPattern) > 0 && !isPointer {
+		return fmt.Sprintf("must be stored with Git LFS; run: git lfs track '%s'", limits.lfsPattern)
+	}
+	if blob.Size > limits.maxSize {
+		return fmt.Sprintf("size %s exceeds the limit of %s; consider storing it with Git LFS",
+			formatSize(blob.Size), formatSize(limits.maxSize))
+	}
+	if !limits.allowBinary && isBinary(blob.Head) {
+		return "binary content; consider storing it with Git LFS"
+	}
+	return ""
+}
+
+// Units recognized by parseSize, largest first.
+var kSizeUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"B", 1},
+}
+
+// Translate the configuration value into a number of bytes, eg. "500KB" or
+// "10MB". Units are powers of 1024; value without unit is expressed in bytes.
+func parseSize(value string) (int64, error) {
+	number := strings.ToUpper(strings.TrimSpace(value))
+	unit := int64(1)
+	for _, u := range kSizeUnits {
+		if strings.HasSuffix(number, u.suffix) {
+			number = strings.TrimSpace(strings.TrimSuffix(number, u.suffix))
+			unit = u.size
+			break
+		}
+	}
+
+	size, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || size < 0 {
+		return 0, fmt.Errorf("invalid size %q", value)
+	}
+	return size * unit, nil
+}
+
+// Present the number of bytes in the largest unit it can be expressed in,
+// rounded to one decimal place, eg. "1.5MB".
+func formatSize(size int64) string {
+	for _, u := range kSizeUnits {
+		if size >= u.size {
+			value := strconv.FormatFloat(float64(size)/float64(u.size), 'f', 1, 64)
+			return strings.TrimSuffix(value, ".0") + u.suffix
+		}
+	}
+	return fmt.Sprintf("%dB", size)
+}
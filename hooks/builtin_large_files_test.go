@@ -0,0 +1,106 @@
+package hooks
+
+import (
+	"testing"
+
+	"github.com/tomasz-wiszkowski/git-hooks/repo"
+)
+
+func Test_parseSize(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{"100", 100, false},
+		{"100B", 100, false},
+		{"500KB", 500 << 10, false},
+		{"10 mb", 10 << 20, false},
+		{"2GB", 2 << 30, false},
+		{"", 0, true},
+		{"MB", 0, true},
+		{"1.5MB", 0, true},
+		{"-1KB", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseSize(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseSize() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_formatSize(t *testing.T) {
+	tests := []struct {
+		size int64
+		want string
+	}{
+		{0, "0B"},
+		{1023, "1023B"},
+		{1024, "1KB"},
+		{1536 << 10, "1.5MB"},
+		{3 << 30, "3GB"},
+	}
+	for _, tt := range tests {
+		if got := formatSize(tt.size); got != tt.want {
+			t.Errorf("formatSize(%d) = %v, want %v", tt.size, got, tt.want)
+		}
+	}
+}
+
+func Test_checkFileLimits(t *testing.T) {
+	pointer := []byte(lfsPointerHeader + "\noid sha256:0123\nsize 1048576\n")
+
+	tests := []struct {
+		name    string
+		blob    repo.StagedBlob
+		limits  fileLimits
+		wantErr bool
+	}{
+		{
+			name:   "Small text file",
+			blob:   repo.StagedBlob{Size: 5, Head: []byte("text\n")},
+			limits: fileLimits{maxSize: 10},
+		},
+		{
+			name:    "File too large",
+			blob:    repo.StagedBlob{Size: 11, Head: []byte("text\n")},
+			limits:  fileLimits{maxSize: 10},
+			wantErr: true,
+		},
+		{
+			name:    "Binary file",
+			blob:    repo.StagedBlob{Size: 3, Head: []byte("a\x00b")},
+			limits:  fileLimits{maxSize: 10},
+			wantErr: true,
+		},
+		{
+			name:   "Binary file allowed",
+			blob:   repo.StagedBlob{Size: 3, Head: []byte("a\x00b")},
+			limits: fileLimits{maxSize: 10, allowBinary: true},
+		},
+		{
+			name:    "File not stored with LFS",
+			blob:    repo.StagedBlob{Size: 3, Head: []byte("a\x00b")},
+			limits:  fileLimits{maxSize: 10, allowBinary: true, lfsPattern: "*.bin"},
+			wantErr: true,
+		},
+		{
+			name:   "LFS pointer",
+			blob:   repo.StagedBlob{Size: int64(len(pointer)), Head: pointer},
+			limits: fileLimits{maxSize: 1024, lfsPattern: "*.bin"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := checkFileLimits(tt.blob, tt.limits); (len(got) > 0) != tt.wantErr {
+				t.Errorf("checkFileLimits() = %q, wantErr %v", got, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package hooks
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/tomasz-wiszkowski/git-hooks/config"
+)
+
+const (
+	// Configuration keys toggling individual push policy rules.
+	keyProtectRefs          = "protectRefs"
+	keyRejectNonFastForward = "rejectNonFastForward"
+	keyRejectWipCommits     = "rejectWipCommits"
+
+	// Prefix of branch refs.
+	branchRefPrefix = "refs/heads/"
+)
+
+// Subjects of commits that should not be pushed: autosquash commits, and work in progress.
+var kWipSubject = regexp.MustCompile(`^(?:fixup!|squash!|amend!|(?i:\[?wip\b))`)
+
+// Options of the push policy action.
+type pushPolicyOptions struct {
+	// Patterns of refs that must not be pushed to, eg. "main" or "release/*".
+	ProtectedRefs []string `json:"protectedRefs"`
+	// Whether pushes to protected refs are rejected.
+	ProtectRefs bool `json:"protectRefs"`
+	// Whether updates discarding remote commits are rejected.
+	RejectNonFastForward bool `json:"rejectNonFastForward"`
+	// Whether pushing autosquash and work in progress commits is rejected.
+	RejectWipCommits bool `json:"rejectWipCommits"`
+}
+
+// Rejects pushes violating the repository policy.
+type pushPolicyAction struct {
+	builtinAction
+	protected []func(string) bool
+	// Rules applied to every pushed ref. Each can be toggled per repository.
+	protectRefs          bool
+	rejectNonFastForward bool
+	rejectWipCommits     bool
+}
+
+func init() {
+	registerActionConstructor("builtin:push-policy", newPushPolicyAction)
+}
+
+// Create a new pushPolicyAction from the supplied configuration.
+func newPushPolicyAction(id string, cfg *actionConfig, onFailure FailurePolicy) (*pushPolicyAction, error) {
+	opts := pushPolicyOptions{
+		ProtectedRefs:        []string{"main", "master"},
+		ProtectRefs:          true,
+		RejectNonFastForward: true,
+		RejectWipCommits:     true,
+	}
+	if err := decodeOptions(cfg.Options, &opts); err != nil {
+		return nil, err
+	}
+
+	action := &pushPolicyAction{
+		builtinAction:        newBuiltinAction(id, cfg.Name, cfg.Priority, onFailure),
+		protectRefs:          opts.ProtectRefs,
+		rejectNonFastForward: opts.RejectNonFastForward,
+		rejectWipCommits:     opts.RejectWipCommits,
+	}
+
+	for _, pattern := range opts.ProtectedRefs {
+		matcher, err := newPathMatcher(pattern)
+		if err != nil {
+			return nil, err
+		}
+		action.protected = append(action.protected, matcher)
+	}
+
+	return action, nil
+}
+
+// Specify the configuration section responsible for managing the hook data.
+func (a *pushPolicyAction) SetConfig(cfg config.Config) {
+	a.actionBase.SetConfig(cfg)
+
+	a.protectRefs = getConfigBool(cfg, keyProtectRefs, a.protectRefs)
+	a.rejectNonFastForward = getConfigBool(cfg, keyRejectNonFastForward, a.rejectNonFastForward)
+	a.rejectWipCommits = getConfigBool(cfg, keyRejectWipCommits, a.rejectWipCommits)
+}
+
+// Apply enabled rules to every ref being pushed.
+func (a *pushPolicyAction) Run(ctx *RunContext) RunResult {
+	if !a.IsSelected() {
+		return RunSkipped
+	}
+	if len(ctx.PushRefs) == 0 {
+		return RunSkipped
+	}
+
+	result := RunSucceeded
+	reject := func(format string, args ...interface{}) {
+		fmt.Printf("%s: %s\n", a.Name(), fmt.Sprintf(format, args...))
+		result = RunFailed
+	}
+
+	for _, ref := range ctx.PushRefs {
+		if a.protectRefs && isProtectedRef(a.protected, ref.RemoteRef) {
+			if ref.IsDelete() {
+				reject("%s is protected and can't be deleted", ref.RemoteRef)
+			} else {
+				reject("%s is protected and can't be pushed to", ref.RemoteRef)
+			}
+		}
+
+		if ref.IsDelete() {
+			continue
+		}
+
+		if a.rejectNonFastForward && !ref.IsNew() {
+			ff, err := ctx.Repo.IsFastForward(ref.RemoteSha, ref.LocalSha)
+			if err != nil {
+				reject("unable to check ancestry of %s: %v", ref.RemoteRef, err)
+			} else if !ff {
+				reject("%s would discard remote commits (non-fast-forward update)", ref.RemoteRef)
+			}
+		}
+
+		if a.rejectWipCommits {
+			for _, c := range ctx.Repo.GetListOfPushedCommits(ref.LocalSha, ref.RemoteSha) {
+				if isWipSubject(c.Subject) {
+					reject("%s: commit %.10s is not ready to be pushed: %s", ref.RemoteRef, c.Sha, c.Subject)
+				}
+			}
+		}
+	}
+	return result
+}
+
+// Check whether the ref matches any of the protected ref patterns. Branch
+// refs are matched by their short name, eg. "main", while other refs are
+// matched by their full name, eg. "refs/tags/v1.0".
+func isProtectedRef(patterns []func(string) bool, ref string) bool {
+	return matchAny(patterns, strings.TrimPrefix(ref, branchRefPrefix))
+}
+
+// Check whether the commit subject marks autosquash or work in progress commit.
+func isWipSubject(subject string) bool {
+	return kWipSubject.MatchString(strings.TrimSpace(subject))
+}
+
+// Retrieve the boolean value for key, substituting dflt if no value is set.
+func getConfigBool(cfg config.Config, key string, dflt bool) bool {
+	if !cfg.Has(key) {
+		return dflt
+	}
+	return cfg.GetOrDefault(key, "") == valueTrue
+}
@@ -0,0 +1,182 @@
+package hooks
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/tomasz-wiszkowski/git-hooks/repo"
+)
+
+func Test_isProtectedRef(t *testing.T) {
+	patterns := []func(string) bool{}
+	for _, p := range []string{"main", "release/*", "refs/tags/**"} {
+		matcher, err := newPathMatcher(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		patterns = append(patterns, matcher)
+	}
+
+	tests := []struct {
+		ref  string
+		want bool
+	}{
+		{"refs/heads/main", true},
+		{"refs/heads/main-fix", false},
+		{"refs/heads/release/1.0", true},
+		{"refs/heads/release/1.0/hotfix", false},
+		{"refs/heads/feature/release/1.0", false},
+		{"refs/tags/v1.0", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			if got := isProtectedRef(patterns, tt.ref); got != tt.want {
+				t.Errorf("isProtectedRef() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_isWipSubject(t *testing.T) {
+	tests := []struct {
+		subject string
+		want    bool
+	}{
+		{"fixup! Add parser", true},
+		{"squash! Add parser", true},
+		{"amend! Add parser", true},
+		{"WIP", true},
+		{"wip: parser", true},
+		{"[WIP] Add parser", true},
+		{"Add parser", false},
+		{"Wipe cache on exit", false},
+		{"Add fixup! handling", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.subject, func(t *testing.T) {
+			if got := isWipSubject(tt.subject); got != tt.want {
+				t.Errorf("isWipSubject() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Create a repository with a chain of commits with the supplied messages,
+// and open it. Returns the repository and the hashes of the commits.
+func newPushTestRepo(t *testing.T, messages ...string) (repo.Repo, []string) {
+	dir := t.TempDir()
+	r, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt, err := r.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hashes := []string{}
+	for i, message := range messages {
+		name := fmt.Sprintf("file%d.txt", i)
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(message), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := wt.Add(name); err != nil {
+			t.Fatal(err)
+		}
+		hash, err := wt.Commit(message, &git.CommitOptions{
+			Author: &object.Signature{Name: "Test", Email: "test@example.com", When: time.Now()},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		hashes = append(hashes, hash.String())
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	opened, err := repo.TryOpenRepo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return opened, hashes
+}
+
+func Test_pushPolicyAction_Run(t *testing.T) {
+	r, commits := newPushTestRepo(t, "first", "WIP: second", "third")
+
+	tests := []struct {
+		name      string
+		config    fakeConfig
+		remoteSha string
+		localSha  string
+		want      RunResult
+	}{
+		{
+			name:      "Fast-forward",
+			remoteSha: commits[1],
+			localSha:  commits[2],
+			want:      RunSucceeded,
+		},
+		{
+			name:      "Non-fast-forward rejected",
+			remoteSha: commits[2],
+			localSha:  commits[0],
+			want:      RunFailed,
+		},
+		{
+			name:      "Non-fast-forward permitted",
+			config:    fakeConfig{keyRejectNonFastForward: "false"},
+			remoteSha: commits[2],
+			localSha:  commits[0],
+			want:      RunSucceeded,
+		},
+		{
+			name:      "WIP commit rejected",
+			remoteSha: commits[0],
+			localSha:  commits[2],
+			want:      RunFailed,
+		},
+		{
+			name:      "WIP commit permitted",
+			config:    fakeConfig{keyRejectWipCommits: "false"},
+			remoteSha: commits[0],
+			localSha:  commits[2],
+			want:      RunSucceeded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action, err := newPushPolicyAction("id", &actionConfig{Name: "Push policy"}, FailureBlocks)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cfg := fakeConfig{keyEnabled: valueTrue}
+			for key, value := range tt.config {
+				cfg[key] = value
+			}
+			action.SetConfig(cfg)
+
+			ctx := &RunContext{HookID: "pre-push", Repo: r, PushRefs: []PushRef{{
+				LocalRef:  "refs/heads/feature",
+				LocalSha:  tt.localSha,
+				RemoteRef: "refs/heads/feature",
+				RemoteSha: tt.remoteSha,
+			}}}
+			if got := action.Run(ctx); got != tt.want {
+				t.Errorf("Run() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,337 @@
+package hooks
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/go-git/go-git/v5/utils/diff"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+const (
+	// Marker suppressing findings on the line it appears on.
+	secretAllowMarker = "githooks:allow-secret"
+
+	// Identifier of the rule detecting high-entropy tokens.
+	secretRuleHighEntropy = "high-entropy"
+
+	// Default minimum entropy of a token, in bits per character.
+	defaultSecretEntropy = 4.5
+
+	// Number of leading characters of the secret presented in findings.
+	secretVisibleLength = 4
+)
+
+// Options of the secret scanner action.
+type secretsOptions struct {
+	// Additional user-defined rules.
+	Rules []secretRuleOptions `json:"rules"`
+	// Identifiers of built-in rules that should not be applied.
+	Disable []string `json:"disable"`
+	// Minimum entropy of a high-entropy token, in bits per character.
+	Entropy float64 `json:"entropy"`
+	// Path of the baseline file, relative to the repository root.
+	Baseline string `json:"baseline"`
+}
+
+// User-defined secret detection rule.
+type secretRuleOptions struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+	// Regular expression matching the secret. If the expression has
+	// a capturing group, the first group is the secret.
+	Regex string `json:"regex"`
+}
+
+// A rule detecting secrets.
+type secretRule struct {
+	id          string
+	description string
+	pattern     *regexp.Regexp
+	// Minimum entropy of the secret, in bits per character. Zero if not checked.
+	entropy float64
+}
+
+// A single line added by the staged modifications.
+type addedLine struct {
+	// Line number, starting at 1.
+	number int
+	text   string
+}
+
+// A secret found in a file.
+type secretFinding struct {
+	file   string
+	line   int
+	rule   *secretRule
+	secret string
+}
+
+// Scans lines added by the staged modifications for secrets.
+type secretsAction struct {
+	builtinAction
+	filter   *fileFilter
+	rules    []*secretRule
+	baseline string
+}
+
+// Built-in rules, most specific first.
+func builtinSecretRules(entropy float64) []*secretRule {
+	return []*secretRule{
+		{
+			id:          "aws-access-key-id",
+			description: "AWS access key ID",
+			pattern:     regexp.MustCompile(`\b((?:AKIA|ASIA|AGPA|AIDA|AROA|AIPA|ANPA|ANVA)[0-9A-Z]{16})\b`),
+		},
+		{
+			id:          "aws-secret-access-key",
+			description: "AWS secret access key",
+			pattern:     regexp.MustCompile(`(?i)aws.{0,20}?(?:secret|key).{0,20}?['"=:\s]([A-Za-z0-9/+]{40})\b`),
+		},
+		{
+			id:          "private-key",
+			description: "private key",
+			pattern:     regexp.MustCompile(`-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY(?: BLOCK)?-----`),
+		},
+		{
+			id:          "password-assignment",
+			description: "password assignment",
+			pattern:     regexp.MustCompile(`(?i)\b(?:password|passwd|pwd)\s*[:=]\s*['"]?([^\s'"$<{=][^\s'"]{3,})`),
+		},
+		{
+			id:          secretRuleHighEntropy,
+			description: "high-entropy token",
+			pattern:     regexp.MustCompile(`[A-Za-z0-9+/_\-]{20,}={0,2}`),
+			entropy:     entropy,
+		},
+	}
+}
+
+func init() {
+	registerActionConstructor("builtin:secrets", newSecretsAction)
+}
+
+// Create a new secretsAction from the supplied configuration.
+func newSecretsAction(id string, cfg *actionConfig, onFailure FailurePolicy) (*secretsAction, error) {
+	opts := secretsOptions{Entropy: defaultSecretEntropy}
+	if err := decodeOptions(cfg.Options, &opts); err != nil {
+		return nil, err
+	}
+
+	filter, err := newFileFilter(cfg.Pattern, cfg.Include, cfg.Exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	action := &secretsAction{
+		builtinAction: newBuiltinAction(id, cfg.Name, cfg.Priority, onFailure),
+		filter:        filter,
+		baseline:      opts.Baseline,
+	}
+
+	builtins := builtinSecretRules(opts.Entropy)
+	for _, disabled := range opts.Disable {
+		found := false
+		for _, rule := range builtins {
+			found = found || rule.id == disabled
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown rule %s", disabled)
+		}
+	}
+
+	for _, rule := range builtins {
+		if !containsString(opts.Disable, rule.id) {
+			action.rules = append(action.rules, rule)
+		}
+	}
+
+	for _, r := range opts.Rules {
+		if len(r.ID) == 0 {
+			return nil, fmt.Errorf("rule id missing")
+		}
+		pattern, err := regexp.Compile(r.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex for rule %s: %w", r.ID, err)
+		}
+		description := r.Description
+		if len(description) == 0 {
+			description = r.ID
+		}
+		action.rules = append(action.rules, &secretRule{id: r.ID, description: description, pattern: pattern})
+	}
+
+	return action, nil
+}
+
+// Scan lines added to every matching file for secrets, reporting findings
+// that are neither suppressed nor present in the baseline.
+func (a *secretsAction) Run(ctx *RunContext) RunResult {
+	if !a.IsSelected() {
+		return RunSkipped
+	}
+
+	matches := a.filter.Filter(ctx.Files)
+	if len(matches) == 0 {
+		return RunSkipped
+	}
+
+	baseline, err := loadSecretsBaseline(a.baseline)
+	if err != nil {
+		fmt.Println(a.Name(), "failed to read baseline:", err)
+		return RunFailed
+	}
+
+	contents, err := ctx.Repo.GetStagedContents(matches)
+	if err != nil {
+		fmt.Println(a.Name(), "failed to inspect staged files:", err)
+		return RunFailed
+	}
+
+	result := RunSucceeded
+	for _, file := range matches {
+		content, ok := contents[file]
+		if !ok || isBinary(content.Current) {
+			continue
+		}
+
+		for _, f := range findSecrets(file, getAddedLines(content.Previous, content.Current), a.rules) {
+			fingerprint := f.fingerprint()
+			if baseline[fingerprint] {
+				continue
+			}
+			fmt.Printf("%s: %s:%d: %s %s (fingerprint %s)\n", a.Name(), f.file, f.line, f.rule.description, redactSecret(f.secret), fingerprint)
+			result = RunFailed
+		}
+	}
+	return result
+}
+
+// Return the lines present in after, but not in before.
+func getAddedLines(before, after []byte) []addedLine {
+	lines := []addedLine{}
+	number := 0
+	for _, d := range diff.Do(string(before), string(after)) {
+		if d.Type == diffmatchpatch.DiffDelete {
+			continue
+		}
+		for _, text := range strings.SplitAfter(d.Text, "\n") {
+			if len(text) == 0 {
+				continue
+			}
+			number++
+			if d.Type == diffmatchpatch.DiffInsert {
+				lines = append(lines, addedLine{number, strings.TrimRight(text, "\r\n")})
+			}
+		}
+	}
+	return lines
+}
+
+// Apply the rules to every line, skipping lines with the suppression marker.
+// Every secret is reported once per line, by the first matching rule.
+func findSecrets(file string, lines []addedLine, rules []*secretRule) []secretFinding {
+	findings := []secretFinding{}
+	for _, line := range lines {
+		if strings.Contains(line.text, secretAllowMarker) {
+			continue
+		}
+
+		reported := map[string]bool{}
+		for _, rule := range rules {
+			for _, match := range rule.pattern.FindAllStringSubmatch(line.text, -1) {
+				secret := match[0]
+				if len(match) > 1 && len(match[1]) > 0 {
+					secret = match[1]
+				}
+				if reported[secret] || !rule.accepts(secret) {
+					continue
+				}
+				reported[secret] = true
+				findings = append(findings, secretFinding{file, line.number, rule, secret})
+			}
+		}
+	}
+	return findings
+}
+
+// Check whether the matched text satisfies the entropy requirements of the rule.
+// Tokens are required to mix lower case letters, upper case letters and digits,
+// to rule out long identifiers.
+func (r *secretRule) accepts(secret string) bool {
+	if r.entropy == 0 {
+		return true
+	}
+	if !strings.ContainsAny(secret, "0123456789") ||
+		!strings.ContainsAny(secret, "abcdefghijklmnopqrstuvwxyz") ||
+		!strings.ContainsAny(secret, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") {
+		return false
+	}
+	return shannonEntropy(secret) >= r.entropy
+}
+
+// Compute the Shannon entropy of the text, in bits per character.
+func shannonEntropy(text string) float64 {
+	counts := map[rune]int{}
+	total := 0
+	for _, c := range text {
+		counts[c]++
+		total++
+	}
+
+	entropy := 0.0
+	for _, count := range counts {
+		p := float64(count) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// Return the identifier of the finding, stable across line number changes,
+// that can be recorded in the baseline file without revealing the secret.
+func (f *secretFinding) fingerprint() string {
+	sum := sha256.Sum256([]byte(f.file + "\x00" + f.rule.id + "\x00" + f.secret))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Mask the secret, retaining only the few leading characters.
+func redactSecret(secret string) string {
+	if len(secret) <= 2*secretVisibleLength {
+		return "****"
+	}
+	return secret[:secretVisibleLength] + "****"
+}
+
+// Read the baseline file, listing fingerprints of accepted findings, one per
+// line. Text following a fingerprint and lines starting with "#" are ignored.
+// Missing file is treated as an empty baseline.
+func loadSecretsBaseline(name string) (map[string]bool, error) {
+	baseline := map[string]bool{}
+	if len(name) == 0 {
+		return baseline, nil
+	}
+
+	file, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return baseline, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) > 0 && !strings.HasPrefix(fields[0], "#") {
+			baseline[fields[0]] = true
+		}
+	}
+	return baseline, scanner.Err()
+}
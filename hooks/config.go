@@ -2,9 +2,13 @@ package hooks
 
 import (
 	"encoding/json"
-	"io/ioutil"
+	"errors"
+	"fmt"
+	"log"
 	"os"
 	"path"
+	"strings"
+	"time"
 
 	"github.com/tomasz-wiszkowski/git-hooks/check"
 )
@@ -12,14 +16,64 @@ import (
 const (
 	configRunTypePerFile   = "perFile"
 	configRunTypePerCommit = "perCommit"
+	configRunTypeBatch     = "batch"
+
+	configActionTypeShell = "shell"
+)
+
+// Config file an entry is defined in.
+type ConfigSource int8
+
+const (
+	// Defined in the user config file, eg. ~/.githooks.json.
+	SourceUser ConfigSource = iota
+	// Defined in the repository config file, eg. .githooks.json at the worktree root.
+	SourceRepo
 )
 
+// Return the user-friendly representation of the source.
+func (s ConfigSource) String() string {
+	switch s {
+	case SourceUser:
+		return "user"
+	case SourceRepo:
+		return "repo"
+	}
+	return "unknown"
+}
+
 type actionConfig struct {
+	Type     string   `json:"type"`
 	Name     string   `json:"name"`
 	RunType  string   `json:"runType"`
 	Priority int32    `json:"priority"`
+	Severity string   `json:"severity"`
 	Pattern  string   `json:"filePattern"`
+	Include  []string `json:"include"`
+	Exclude  []string `json:"exclude"`
 	ShellCmd []string `json:"shellCmd"`
+	Fixer    bool     `json:"fixer"`
+	OnFix    string   `json:"onFix"`
+	Timeout  string   `json:"timeout"`
+	Output   string   `json:"output"`
+	// Options specific to the action type.
+	Options json.RawMessage `json:"options"`
+	// Description of the action, presented to the user.
+	Description string `json:"description"`
+	// Tags classifying the action, presented to the user.
+	Tags []string `json:"tags"`
+	// Environment variables set for shell commands.
+	Env map[string]string `json:"env"`
+	// Directory shell commands are run in, relative to the worktree root.
+	WorkingDir string `json:"workingDir"`
+	// Action this action inherits its definition from. Used only while the
+	// config files are read.
+	Extends string `json:"extends"`
+	// Whether the action defined in the repository config replaces the user
+	// action with the same ID.
+	Override bool `json:"override"`
+	// Config file the action is defined in.
+	source ConfigSource
 }
 
 type hookConfig struct {
@@ -28,35 +82,56 @@ type hookConfig struct {
 }
 
 type topConfig struct {
-	Version int32                  `json:"version"`
-	Hooks   map[string]*hookConfig `json:"hooks"`
+	Version        int32                  `json:"version"`
+	Workers        int                    `json:"workers"`
+	StashUnstaged  *bool                  `json:"stashUnstaged"`
+	DefaultTimeout string                 `json:"defaultTimeout"`
+	Hooks          map[string]*hookConfig `json:"hooks"`
+	// Config files merged into this one. Used only while the files are read.
+	Include []string `json:"include"`
 }
 
-// Load user settings from ~/.githooks.config file.
-// If the file is installed and valid, returns deserialized content.
-// If the file is missing or is empty, returns an empty map.
-// All other cases cause assertion failure.
-func loadConfigFile() map[string]Hook {
-	name, err := os.UserHomeDir()
-	check.Err(err, "Unable to query user home directory")
-
+// Load user settings from ~/.githooks.json file, and the repository settings
+// from .githooks.json file at the repository worktree root, if the root is known.
+// If the files are installed and valid, returns merged deserialized content.
+// If the files are missing or are empty, returns an empty map and default settings.
+// If the repository config is not valid, its problems are reported, and only
+// the user config is used. If the user config is not valid, all the problems
+// found are reported, and the process exits.
+func loadConfigFile() (map[string]Hook, *Settings) {
 	result := map[string]Hook{}
+	settings := newDefaultSettings()
 
-	content, err := ioutil.ReadFile(path.Join(name, ".githooks.json"))
-	if err != nil {
-		return result
+	config, userProblems, repoProblems := readConfigs()
+	// Problems of the repository config must not block the user's own actions.
+	if len(repoProblems) > 0 {
+		for _, problem := range repoProblems {
+			fmt.Fprintln(os.Stderr, problem)
+		}
+		log.Println("Ignoring repository config:", len(repoProblems), "problem(s) found")
+	}
+	if len(userProblems) > 0 {
+		for _, problem := range userProblems {
+			fmt.Fprintln(os.Stderr, problem)
+		}
+		log.Fatalln("Invalid config:", len(userProblems), "problem(s) found")
 	}
 
-	var config topConfig
-	err = json.Unmarshal(content, &config)
-	check.Err(err, "Malformed config file")
-
-	// Assume Version 0 = no config.
-	if config.Version == 0 {
-		return result
+	if config == nil {
+		return result, settings
 	}
 
-	check.True(config.Version == 1, "Unsupported config file version %d", config.Version)
+	check.True(config.Workers >= 0, "Invalid number of workers %d", config.Workers)
+
+	if config.Workers > 0 {
+		settings.Workers = config.Workers
+	}
+	if config.StashUnstaged != nil {
+		settings.StashUnstaged = *config.StashUnstaged
+	}
+	var err error
+	settings.DefaultTimeout, err = parseTimeout(config.DefaultTimeout)
+	check.Err(err, "Invalid defaultTimeout")
 
 	for ck, cv := range config.Hooks {
 		hooks := []Action{}
@@ -68,27 +143,307 @@ func loadConfigFile() map[string]Hook {
 			id:      ck,
 			name:    cv.Name,
 			actions: hooks,
+			info:    map[string]ActionInfo{},
 		}
 
 		for hk, hv := range cv.Actions {
-			runType := runPerFile
-			if hv.RunType == configRunTypePerCommit {
-				runType = runPerCommit
-			} else if hv.RunType != configRunTypePerFile {
-				check.True(false, "Invalid runType %s for hook %s", hv.RunType, hk)
-			}
-
 			check.True(len(hk) > 0, "Invalid hook ID in category %s", ck)
 			check.True(len(hv.Name) > 0, "Invalid hook name for hook %s", hk)
-			check.True(len(hv.ShellCmd) > 0, "Invalid shell command for hook %s", hk)
 
-			hook := newShellAction(hk, hv.Name, hv.Priority, hv.Pattern, hv.ShellCmd, runType)
+			onFailure, ok := parseSeverity(hv.Severity)
+			check.True(ok, "Invalid severity %s for hook %s", hv.Severity, hk)
+
+			actionType := hv.Type
+			if len(actionType) == 0 {
+				actionType = configActionTypeShell
+			}
+			factory, ok := kActionTypes[actionType]
+			check.True(ok, "Invalid type %s for hook %s, expected one of: %s", hv.Type, hk, strings.Join(getActionTypes(), ", "))
+
+			hook, err := factory(hk, hv, onFailure)
+			check.Err(err, "Invalid options for hook %s", hk)
+			if hv.source == SourceRepo {
+				hook = &repoAction{hook, kRepoTrust}
+			}
 			hooks = append(hooks, hook)
+			category.info[hk] = ActionInfo{Source: hv.source, Description: hv.Description, Tags: hv.Tags}
 		}
 
 		category.actions = hooks
 		result[ck] = category
 	}
 
-	return result
+	return result, settings
+}
+
+// Read and validate the user and repository config files, along with all the
+// files they include. Returns the merged config, or nil if there is none, and
+// the problems found in the user and the repository config files, ordered by
+// file and location. The repository config is not merged if it has problems.
+func readConfigs() (*topConfig, []*ConfigError, []*ConfigError) {
+	home, err := os.UserHomeDir()
+	check.Err(err, "Unable to query user home directory")
+
+	readConfigIn := func(dir string, source ConfigSource) (*topConfig, string, []byte, []*ConfigError) {
+		name, err := findConfigFile(dir)
+		if err != nil {
+			return nil, "", nil, []*ConfigError{newConfigError(configLocation{file: dir}, "", err.Error())}
+		}
+		config, content, problems := readConfigFile(name, source)
+		return config, name, content, problems
+	}
+
+	config, userName, _, userProblems := readConfigIn(home, SourceUser)
+
+	var repoConfig *topConfig
+	repoProblems := []*ConfigError{}
+	if kRepo != nil {
+		root := kRepo.WorkDir().Root()
+		// The home directory may itself be a repository worktree.
+		if path.Clean(root) != path.Clean(home) {
+			var repoName string
+			var content []byte
+			repoConfig, repoName, content, repoProblems = readConfigIn(root, SourceRepo)
+			repoProblems = append(repoProblems, findUnnamedHooks(repoName, repoConfig, config)...)
+			if len(repoProblems) > 0 {
+				repoConfig = nil
+			}
+			if repoConfig != nil {
+				kRepoTrust = newRepoTrust(kRepo, path.Base(repoName), content)
+			}
+		}
+	}
+
+	userProblems = append(userProblems, findUnnamedHooks(userName, config, repoConfig)...)
+	sortConfigErrors(userProblems)
+	sortConfigErrors(repoProblems)
+	trusted := kRepoTrust != nil && kRepoTrust.trusted
+	return mergeConfigs(config, repoConfig, trusted), userProblems, repoProblems
+}
+
+// Report hooks of the config file that have no name. Hook names may be
+// defined by either of the files, so hooks named by the other config are not
+// reported.
+func findUnnamedHooks(name string, config, other *topConfig) []*ConfigError {
+	problems := []*ConfigError{}
+	if config == nil {
+		return problems
+	}
+	for hookID, hook := range config.Hooks {
+		if hook == nil || len(hook.Name) > 0 {
+			continue
+		}
+		if other != nil && other.Hooks[hookID] != nil && len(other.Hooks[hookID].Name) > 0 {
+			continue
+		}
+		problems = append(problems, newConfigError(configLocation{file: name}, joinConfigPath(getHookPath(hookID), "name"), "missing hook name"))
+	}
+	return problems
+}
+
+// Read, deserialize and validate the config file, along with all the files it
+// includes, attributing all actions to source. Returns the deserialized config
+// and its normalized content, or nil if the file is missing, is empty or is
+// not valid, along with all the problems found.
+func readConfigFile(name string, source ConfigSource) (*topConfig, []byte, []*ConfigError) {
+	if len(name) == 0 {
+		return nil, nil, nil
+	}
+
+	fail := func(err error) (*topConfig, []byte, []*ConfigError) {
+		var configErr *ConfigError
+		if !errors.As(err, &configErr) {
+			configErr = newConfigError(configLocation{file: name}, "", err.Error())
+		}
+		return nil, nil, []*ConfigError{configErr}
+	}
+
+	reader := newConfigReader()
+	doc, err := reader.readDocument(name, nil, 0)
+	if err != nil {
+		return fail(err)
+	}
+	if doc == nil {
+		return nil, nil, nil
+	}
+
+	if err = resolveExtends(doc); err != nil {
+		return fail(err)
+	}
+
+	content, err := marshalConfigJson(doc, "  ")
+	if err != nil {
+		return fail(err)
+	}
+
+	validator := &configValidator{file: name, locations: reader.locations, problems: reader.problems}
+
+	var config topConfig
+	if err = json.Unmarshal(content, &config); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			validator.report(typeErr.Field, "invalid value, expected %s", typeErr.Type)
+		} else {
+			validator.report("", "%v", err)
+		}
+		return nil, nil, validator.problems
+	}
+
+	// Assume Version 0 = no config.
+	if config.Version == 0 {
+		return nil, nil, validator.problems
+	}
+
+	// All files are brought to the latest version while they are read.
+	if config.Version != configVersionLatest {
+		validator.report(configKeyVersion, "unsupported config file version %d", config.Version)
+		return nil, nil, validator.problems
+	}
+
+	validator.validateConfig(&config)
+	if len(validator.problems) > 0 {
+		return nil, nil, validator.problems
+	}
+
+	for _, cv := range config.Hooks {
+		for _, hv := range cv.Actions {
+			hv.source = source
+		}
+	}
+
+	return &config, content, nil
+}
+
+// Merge the repository config into the user config. Settings and hook names
+// defined in the user config take precedence. Actions defined in the
+// repository config are added to the corresponding hooks, but actions reusing
+// the ID of a user action are ignored, unless marked to override the user
+// action. Settings and overrides of the repository config apply only once the
+// config is trusted, so that an unapproved config can't alter or disable the
+// user's own actions.
+func mergeConfigs(user, repo *topConfig, trusted bool) *topConfig {
+	if repo == nil {
+		return user
+	}
+
+	merged := topConfig{Version: repo.Version}
+	if user != nil {
+		merged = *user
+	}
+	if trusted {
+		if merged.Workers == 0 {
+			merged.Workers = repo.Workers
+		}
+		if merged.StashUnstaged == nil {
+			merged.StashUnstaged = repo.StashUnstaged
+		}
+		if len(merged.DefaultTimeout) == 0 {
+			merged.DefaultTimeout = repo.DefaultTimeout
+		}
+	}
+
+	merged.Hooks = map[string]*hookConfig{}
+	if user != nil {
+		for ck, cv := range user.Hooks {
+			category := &hookConfig{Name: cv.Name, Actions: map[string]*actionConfig{}}
+			for hk, hv := range cv.Actions {
+				category.Actions[hk] = hv
+			}
+			merged.Hooks[ck] = category
+		}
+	}
+
+	for ck, cv := range repo.Hooks {
+		category, ok := merged.Hooks[ck]
+		if !ok {
+			merged.Hooks[ck] = cv
+			continue
+		}
+		if len(category.Name) == 0 {
+			category.Name = cv.Name
+		}
+
+		for hk, hv := range cv.Actions {
+			if _, ok := category.Actions[hk]; ok {
+				if !hv.Override {
+					log.Println("Ignoring repository action", hk, "in", ck, "- the user config defines an action with the same ID")
+					continue
+				}
+				if !trusted {
+					log.Println("Ignoring override of action", hk, "in", ck, "- the repository config is not trusted")
+					continue
+				}
+			}
+			category.Actions[hk] = hv
+		}
+	}
+
+	return &merged
+}
+
+func init() {
+	registerActionType(configActionTypeShell, func(id string, cfg *actionConfig, onFailure FailurePolicy) (Action, error) {
+		return newShellActionFromConfig(id, cfg, onFailure), nil
+	})
+}
+
+// Create a new shellAction from the supplied configuration.
+func newShellActionFromConfig(id string, cfg *actionConfig, onFailure FailurePolicy) *shellAction {
+	runType := runPerFile
+	if cfg.RunType == configRunTypePerCommit {
+		runType = runPerCommit
+	} else if cfg.RunType == configRunTypeBatch {
+		runType = runBatch
+	} else if cfg.RunType != configRunTypePerFile {
+		check.True(false, "Invalid runType %s for hook %s", cfg.RunType, id)
+	}
+
+	onFix, ok := parseFixPolicy(cfg.OnFix)
+	check.True(ok, "Invalid onFix %s for hook %s", cfg.OnFix, id)
+
+	timeout, err := parseTimeout(cfg.Timeout)
+	check.Err(err, "Invalid timeout for hook %s", id)
+
+	output, ok := parseOutputMode(cfg.Output)
+	check.True(ok, "Invalid output %s for hook %s", cfg.Output, id)
+
+	check.True(len(cfg.ShellCmd) > 0, "Invalid shell command for hook %s", id)
+
+	filter, err := newFileFilter(cfg.Pattern, cfg.Include, cfg.Exclude)
+	check.Err(err, "Invalid file patterns for hook %s", id)
+
+	workingDir, err := parseWorkingDir(cfg.WorkingDir)
+	check.Err(err, "Invalid workingDir for hook %s", id)
+
+	action := newShellAction(id, cfg.Name, cfg.Priority, onFailure, filter, cfg.ShellCmd, runType, cfg.Fixer, onFix, timeout, output)
+	action.env = buildEnvironment(cfg.Env)
+	action.workingDir = workingDir
+	return action
+}
+
+// Translate the configuration value into a directory relative to the worktree
+// root. Empty value, or ".", is interpreted as the root itself, and translated
+// into an empty string.
+func parseWorkingDir(value string) (string, error) {
+	dir := path.Clean(value)
+	if path.IsAbs(dir) || dir == ".." || strings.HasPrefix(dir, "../") {
+		return "", fmt.Errorf("directory %s is outside the worktree", value)
+	}
+	if dir == "." {
+		dir = ""
+	}
+	return dir, nil
+}
+
+// Translate the configuration value into a duration, eg. "90s" or "5m".
+// Empty value is interpreted as no timeout.
+func parseTimeout(value string) (time.Duration, error) {
+	if len(value) == 0 {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(value)
+	if err == nil && timeout < 0 {
+		err = fmt.Errorf("negative timeout %s", value)
+	}
+	return timeout, err
 }
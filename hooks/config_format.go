@@ -0,0 +1,292 @@
+package hooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// Name of the config file, without extension, looked up in the user home
+	// directory and at the repository worktree root.
+	configFileBaseName = ".githooks"
+
+	configExtensionJson = ".json"
+	configExtensionYaml = ".yaml"
+	configExtensionYml  = ".yml"
+	configExtensionToml = ".toml"
+)
+
+// Extensions of the supported config file formats, in lookup order.
+var kConfigFileExtensions = []string{configExtensionJson, configExtensionYaml, configExtensionYml, configExtensionToml}
+
+// Locate the config file in the directory. Returns an empty string if there
+// is no config file, and an error if there are several.
+func findConfigFile(dir string) (string, error) {
+	found := []string{}
+	for _, ext := range kConfigFileExtensions {
+		name := filepath.Join(dir, configFileBaseName+ext)
+		if _, err := os.Stat(name); err == nil {
+			found = append(found, name)
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return "", nil
+	case 1:
+		return found[0], nil
+	}
+	return "", fmt.Errorf("multiple config files found: %s", strings.Join(found, ", "))
+}
+
+// Deserialize the content of the config file, according to its extension.
+func decodeConfigDocument(name string, content []byte) (configDocument, error) {
+	doc := configDocument{}
+	var err error
+
+	switch strings.ToLower(filepath.Ext(name)) {
+	case configExtensionJson:
+		decoder := json.NewDecoder(bytes.NewReader(content))
+		decoder.UseNumber()
+		err = decoder.Decode(&doc)
+	case configExtensionYaml, configExtensionYml:
+		err = yaml.Unmarshal(content, &doc)
+	case configExtensionToml:
+		err = toml.Unmarshal(content, &doc)
+	default:
+		return nil, fmt.Errorf("unsupported config file format %s", name)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("malformed content: %w", err)
+	}
+	// Empty YAML documents, and JSON null, leave the document unset.
+	if doc == nil {
+		doc = configDocument{}
+	}
+	return doc, nil
+}
+
+// Serialize the value as indented JSON. Unlike json.MarshalIndent, keeps the
+// placeholders, eg. <file>, readable.
+func marshalConfigJson(v interface{}, indent string) ([]byte, error) {
+	var out bytes.Buffer
+	encoder := json.NewEncoder(&out)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", indent)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(out.Bytes(), []byte("\n")), nil
+}
+
+// Serialize the document, according to the extension of the config file.
+func encodeConfigDocument(name string, doc configDocument) ([]byte, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case configExtensionJson:
+		content, err := marshalConfigJson(doc, "    ")
+		return append(content, '\n'), err
+	case configExtensionYaml, configExtensionYml:
+		return yaml.Marshal(doc)
+	case configExtensionToml:
+		var out bytes.Buffer
+		err := toml.NewEncoder(&out).Encode(doc)
+		return out.Bytes(), err
+	}
+	return nil, fmt.Errorf("unsupported config file format %s", name)
+}
+
+// Location of a value in a config file. Line and column start at 1, and are
+// zero if not known.
+type configLocation struct {
+	file   string
+	line   int
+	column int
+}
+
+// Locations of values in config files, keyed by the path of every value, eg.
+// "hooks.pre-commit.actions.GoFmt.shellCmd[0]".
+type configLocations map[string]configLocation
+
+// Append the object key to the path.
+func joinConfigPath(path, key string) string {
+	if len(path) == 0 {
+		return key
+	}
+	return path + "." + key
+}
+
+// Append the list index to the path.
+func indexConfigPath(path string, index int) string {
+	return fmt.Sprintf("%s[%d]", path, index)
+}
+
+// Record the location of every value defined in the config file, according to
+// its extension. Locations of values in formats that do not report them are
+// recorded without line and column. Returns the problems found while locating
+// values, eg. keys repeated within a JSON object.
+func (l configLocations) add(name string, content []byte, doc configDocument) []*ConfigError {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case configExtensionJson:
+		return l.addJson(name, content)
+	case configExtensionYaml, configExtensionYml:
+		var node yaml.Node
+		if err := yaml.Unmarshal(content, &node); err == nil {
+			l.addYaml(name, "", &node)
+			return nil
+		}
+	}
+	l.addDocument(name, "", doc)
+	return nil
+}
+
+// Record the location of every value of the decoded document.
+func (l configLocations) addDocument(name, path string, value interface{}) {
+	l[path] = configLocation{file: name}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			l.addDocument(name, joinConfigPath(path, key), item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			l.addDocument(name, indexConfigPath(path, i), item)
+		}
+	}
+}
+
+// Record the location of every value of the YAML node.
+func (l configLocations) addYaml(name, path string, node *yaml.Node) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, item := range node.Content {
+			l.addYaml(name, path, item)
+		}
+		return
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			l.addYaml(name, joinConfigPath(path, node.Content[i].Value), node.Content[i+1])
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			l.addYaml(name, indexConfigPath(path, i), item)
+		}
+	}
+	l[path] = configLocation{file: name, line: node.Line, column: node.Column}
+}
+
+// Forget the locations of all values nested in the value at the path.
+func (l configLocations) removeUnder(path string) {
+	for p := range l {
+		if strings.HasPrefix(p, path+".") || strings.HasPrefix(p, path+"[") {
+			delete(l, p)
+		}
+	}
+}
+
+// Record the location of every value of the JSON content. The content must
+// be valid JSON.
+func (l configLocations) addJson(name string, content []byte) []*ConfigError {
+	problems := []*ConfigError{}
+	decoder := json.NewDecoder(bytes.NewReader(content))
+
+	// Locate the token the decoder returns next.
+	next := func() configLocation {
+		offset := int(decoder.InputOffset())
+		for offset < len(content) && strings.IndexByte(" \t\r\n,:", content[offset]) >= 0 {
+			offset++
+		}
+		return offsetLocation(name, content, offset)
+	}
+
+	var walk func(path string) error
+	walk = func(path string) error {
+		l[path] = next()
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+
+		switch token {
+		case json.Delim('{'):
+			seen := map[string]bool{}
+			for decoder.More() {
+				location := next()
+				key, err := decoder.Token()
+				if err != nil {
+					return err
+				}
+				if seen[key.(string)] {
+					// Only the last definition is retained.
+					l.removeUnder(joinConfigPath(path, key.(string)))
+					problems = append(problems, &ConfigError{
+						File:    name,
+						Path:    joinConfigPath(path, key.(string)),
+						Line:    location.line,
+						Column:  location.column,
+						Message: "key defined more than once",
+					})
+				}
+				seen[key.(string)] = true
+				if err = walk(joinConfigPath(path, key.(string))); err != nil {
+					return err
+				}
+			}
+			_, err = decoder.Token()
+		case json.Delim('['):
+			for i := 0; decoder.More(); i++ {
+				if err = walk(indexConfigPath(path, i)); err != nil {
+					return err
+				}
+			}
+			_, err = decoder.Token()
+		}
+		return err
+	}
+
+	// The content has already been decoded, so errors are not expected.
+	walk("")
+	return problems
+}
+
+// Translate the byte offset within the content into a location.
+func offsetLocation(name string, content []byte, offset int) configLocation {
+	if offset > len(content) {
+		offset = len(content)
+	} else if offset < 0 {
+		offset = 0
+	}
+	before := content[:offset]
+	return configLocation{
+		file:   name,
+		line:   bytes.Count(before, []byte("\n")) + 1,
+		column: offset - bytes.LastIndexByte(before, '\n'),
+	}
+}
+
+// Locate the problem reported while decoding the config file, if the format
+// reports its location.
+func decodeErrorLocation(name string, content []byte, err error) configLocation {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var tomlErr toml.ParseError
+
+	switch {
+	case errors.As(err, &syntaxErr):
+		// The offending character is the last one read.
+		return offsetLocation(name, content, int(syntaxErr.Offset)-1)
+	case errors.As(err, &typeErr):
+		return offsetLocation(name, content, int(typeErr.Offset))
+	case errors.As(err, &tomlErr):
+		return offsetLocation(name, content, tomlErr.Position.Start)
+	}
+	return configLocation{file: name}
+}
@@ -0,0 +1,124 @@
+package hooks
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_decodeConfigDocument(t *testing.T) {
+	want := `{"version": 2, "hooks": {"pre-commit": {"name": "Pre-commit", "actions": {"Fmt": {"name": "Fmt", "tags": ["go"], "env": {"GOFLAGS": "-mod=mod"}}}}}}`
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wantErr bool
+	}{
+		{
+			name:    "JSON",
+			file:    ".githooks.json",
+			content: want,
+		},
+		{
+			name: "YAML",
+			file: ".githooks.yaml",
+			content: `version: 2
+hooks:
+  pre-commit:
+    name: Pre-commit
+    actions:
+      Fmt:
+        name: Fmt
+        tags: [go]
+        env:
+          GOFLAGS: -mod=mod
+`,
+		},
+		{
+			name: "TOML",
+			file: ".githooks.toml",
+			content: `version = 2
+[hooks.pre-commit]
+name = "Pre-commit"
+[hooks.pre-commit.actions.Fmt]
+name = "Fmt"
+tags = ["go"]
+env = { GOFLAGS = "-mod=mod" }
+`,
+		},
+		{
+			name:    "Unsupported format",
+			file:    ".githooks.ini",
+			content: want,
+			wantErr: true,
+		},
+		{
+			name:    "Malformed content",
+			file:    ".githooks.yml",
+			content: "hooks: [",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := decodeConfigDocument(tt.file, []byte(tt.content))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeConfigDocument() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got, _ := json.Marshal(doc)
+			if !reflect.DeepEqual(parseDocument(t, string(got)), parseDocument(t, want)) {
+				t.Errorf("decodeConfigDocument() = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func Test_encodeConfigDocument(t *testing.T) {
+	doc := parseDocument(t, `{"version": 2, "hooks": {"pre-commit": {"actions": {"Fmt": {"name": "Fmt", "tags": ["go"]}}}}}`)
+
+	for _, file := range []string{".githooks.json", ".githooks.yaml", ".githooks.toml"} {
+		t.Run(file, func(t *testing.T) {
+			content, err := encodeConfigDocument(file, doc)
+			if err != nil {
+				t.Fatalf("encodeConfigDocument() error = %v", err)
+			}
+			decoded, err := decodeConfigDocument(file, content)
+			if err != nil {
+				t.Fatalf("decodeConfigDocument() error = %v", err)
+			}
+			got, _ := json.Marshal(decoded)
+			if !reflect.DeepEqual(parseDocument(t, string(got)), doc) {
+				t.Errorf("round trip = %s, want %v", got, doc)
+			}
+		})
+	}
+}
+
+func Test_findConfigFile(t *testing.T) {
+	dir := t.TempDir()
+
+	if name, err := findConfigFile(dir); name != "" || err != nil {
+		t.Errorf("findConfigFile() = %q, %v in empty directory", name, err)
+	}
+
+	yaml := filepath.Join(dir, ".githooks.yaml")
+	if err := os.WriteFile(yaml, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if name, err := findConfigFile(dir); name != yaml || err != nil {
+		t.Errorf("findConfigFile() = %q, %v, want %q", name, err, yaml)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, ".githooks.json"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := findConfigFile(dir); err == nil {
+		t.Errorf("findConfigFile() succeeded with multiple config files")
+	}
+}
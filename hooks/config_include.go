@@ -0,0 +1,269 @@
+package hooks
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// Key listing the config files included by a config file.
+	configKeyInclude = "include"
+	// Key naming the action an action inherits its definition from.
+	configKeyExtends = "extends"
+	// Separator of the hook ID and the action ID in the extends value.
+	extendsSeparator = "/"
+)
+
+// Deserialized JSON object.
+type configDocument = map[string]interface{}
+
+// Reads config files, along with all the files they include, and tracks
+// where every value of the merged document is defined.
+type configReader struct {
+	// Location of every value of the merged document.
+	locations configLocations
+	// Problems that do not prevent the files from being merged.
+	problems []*ConfigError
+}
+
+// Create a new configReader.
+func newConfigReader() *configReader {
+	return &configReader{
+		locations: configLocations{},
+		problems:  []*ConfigError{},
+	}
+}
+
+// Read the config file, along with all the files it includes, and merge them
+// into a single document. Included files are merged in the order they are
+// listed, followed by the including file itself, so that later files override
+// earlier ones. Files on the same include list must not define the same
+// action. Every file is brought to the latest version of the format before it
+// is merged; files not specifying the version assume the version of the
+// including file. The stack lists the files including this one, and is used
+// to detect include cycles. Returns nil if the file is missing and is not
+// included by another file.
+func (r *configReader) readDocument(name string, stack []string, version int) (configDocument, error) {
+	name, err := filepath.Abs(name)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, including := range stack {
+		if including == name {
+			return nil, fmt.Errorf("include cycle: %s", strings.Join(append(stack[i:len(stack):len(stack)], name), " -> "))
+		}
+	}
+
+	content, err := ioutil.ReadFile(name)
+	if err != nil {
+		if len(stack) == 0 && os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	doc, err := decodeConfigDocument(name, content)
+	if err != nil {
+		return nil, newConfigError(decodeErrorLocation(name, content, err), "", err.Error())
+	}
+
+	if _, ok := doc[configKeyVersion]; ok {
+		if version, err = getDocumentVersion(doc); err != nil {
+			return nil, newConfigError(configLocation{file: name}, configKeyVersion, err.Error())
+		}
+	}
+	if err = upgradeDocument(doc, version); err != nil {
+		return nil, newConfigError(configLocation{file: name}, "", err.Error())
+	}
+
+	includes, err := getIncludes(doc)
+	if err != nil {
+		return nil, newConfigError(configLocation{file: name}, configKeyInclude, err.Error())
+	}
+	delete(doc, configKeyInclude)
+
+	result := configDocument{}
+	stack = append(stack[:len(stack):len(stack)], name)
+	// File defining every action, keyed by the hook and action IDs.
+	defined := map[string]string{}
+	for _, include := range includes {
+		included, err := r.readDocument(expandIncludePath(include, filepath.Dir(name)), stack, version)
+		if err != nil {
+			var configErr *ConfigError
+			if !errors.As(err, &configErr) {
+				err = newConfigError(configLocation{file: name}, configKeyInclude, err.Error())
+			}
+			return nil, err
+		}
+
+		forEachActionDocument(included, func(id string, _ configDocument) error {
+			location := r.locations[getActionPath(id)]
+			if file, ok := defined[id]; ok && file != location.file {
+				r.problems = append(r.problems, newConfigError(location, getActionPath(id),
+					fmt.Sprintf("action %s is already defined in %s; change it in %s instead", id, file, name)))
+			}
+			defined[id] = location.file
+			return nil
+		})
+		result = mergeDocuments(result, included)
+	}
+
+	// The file itself is merged last, so its locations take precedence.
+	r.problems = append(r.problems, r.locations.add(name, content, doc)...)
+	return mergeDocuments(result, doc), nil
+}
+
+// Return the list of files included by the document.
+func getIncludes(doc configDocument) ([]string, error) {
+	value, ok := doc[configKeyInclude]
+	if !ok || value == nil {
+		return nil, nil
+	}
+
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid %s, expected list of paths", configKeyInclude)
+	}
+
+	includes := []string{}
+	for _, item := range list {
+		include, ok := item.(string)
+		if !ok || len(include) == 0 {
+			return nil, fmt.Errorf("invalid %s entry %v", configKeyInclude, item)
+		}
+		includes = append(includes, include)
+	}
+	return includes, nil
+}
+
+// Translate the include path into a file name. Environment variables are
+// expanded, with $XDG_CONFIG_HOME defaulting to ~/.config, and relative paths
+// are resolved against the directory of the including file.
+func expandIncludePath(include, dir string) string {
+	expanded := os.Expand(include, func(name string) string {
+		value := os.Getenv(name)
+		if name == "XDG_CONFIG_HOME" && len(value) == 0 {
+			if home, err := os.UserHomeDir(); err == nil {
+				value = filepath.Join(home, ".config")
+			}
+		}
+		return value
+	})
+
+	if strings.HasPrefix(expanded, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			expanded = filepath.Join(home, expanded[2:])
+		}
+	}
+
+	if !filepath.IsAbs(expanded) {
+		expanded = filepath.Join(dir, expanded)
+	}
+	return expanded
+}
+
+// Merge the overlay into the base document, returning the merged document.
+// Objects are merged recursively; all other values in the overlay replace
+// the corresponding values in the base. A null value in the overlay removes
+// the corresponding value. Neither document is modified.
+func mergeDocuments(base, overlay configDocument) configDocument {
+	result := configDocument{}
+	for key, value := range base {
+		result[key] = value
+	}
+
+	for key, value := range overlay {
+		if value == nil {
+			delete(result, key)
+			continue
+		}
+
+		// Objects are merged even if there's nothing to merge them with, to
+		// remove null values.
+		if overlayObject, ok := value.(map[string]interface{}); ok {
+			baseObject, _ := result[key].(map[string]interface{})
+			result[key] = mergeDocuments(baseObject, overlayObject)
+		} else {
+			result[key] = value
+		}
+	}
+	return result
+}
+
+// Replace every action extending another action with the merge of the two.
+// The extended action is identified by its ID, if it's defined for the same
+// hook, or by the hook ID and action ID separated with "/", eg.
+// "pre-commit/GoFmt".
+func resolveExtends(doc configDocument) error {
+	hooks, _ := doc["hooks"].(map[string]interface{})
+
+	getActions := func(hookID string) map[string]interface{} {
+		hook, _ := hooks[hookID].(map[string]interface{})
+		actions, _ := hook["actions"].(map[string]interface{})
+		return actions
+	}
+
+	var resolve func(hookID, actionID string, stack []string) (configDocument, error)
+	resolve = func(hookID, actionID string, stack []string) (configDocument, error) {
+		key := hookID + extendsSeparator + actionID
+		for i, extending := range stack {
+			if extending == key {
+				return nil, fmt.Errorf("extends cycle: %s", strings.Join(append(stack[i:len(stack):len(stack)], key), " -> "))
+			}
+		}
+
+		action, ok := getActions(hookID)[actionID].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unknown action %s", key)
+		}
+
+		value, ok := action[configKeyExtends]
+		if !ok {
+			return action, nil
+		}
+		extends, ok := value.(string)
+		if !ok || len(extends) == 0 {
+			return nil, fmt.Errorf("invalid %s value for action %s", configKeyExtends, key)
+		}
+
+		baseHookID, baseActionID := hookID, extends
+		if parts := strings.SplitN(extends, extendsSeparator, 2); len(parts) == 2 {
+			baseHookID, baseActionID = parts[0], parts[1]
+		}
+
+		base, err := resolve(baseHookID, baseActionID, append(stack[:len(stack):len(stack)], key))
+		if err != nil {
+			return nil, err
+		}
+
+		resolved := mergeDocuments(base, action)
+		delete(resolved, configKeyExtends)
+		getActions(hookID)[actionID] = resolved
+		return resolved, nil
+	}
+
+	for _, hookID := range sortedKeys(hooks) {
+		for _, actionID := range sortedKeys(getActions(hookID)) {
+			if _, err := resolve(hookID, actionID, nil); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Return the keys of the map in ascending order.
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
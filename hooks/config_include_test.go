@@ -0,0 +1,174 @@
+package hooks
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Deserialize the JSON text into a document.
+func parseDocument(t *testing.T, text string) configDocument {
+	var doc configDocument
+	if err := json.Unmarshal([]byte(text), &doc); err != nil {
+		t.Fatalf("invalid JSON %s: %v", text, err)
+	}
+	return doc
+}
+
+func Test_mergeDocuments(t *testing.T) {
+	tests := []struct {
+		name    string
+		base    string
+		overlay string
+		want    string
+	}{
+		{
+			name:    "Fields overridden",
+			base:    `{"a": 1, "b": [1, 2], "c": "x"}`,
+			overlay: `{"b": [3], "c": "y", "d": true}`,
+			want:    `{"a": 1, "b": [3], "c": "y", "d": true}`,
+		},
+		{
+			name:    "Objects merged recursively",
+			base:    `{"hooks": {"pre-commit": {"actions": {"Fmt": {"name": "Fmt", "filePattern": "\\.go$"}}}}}`,
+			overlay: `{"hooks": {"pre-commit": {"actions": {"Fmt": {"filePattern": "\\.rs$"}, "Vet": {"name": "Vet"}}}}}`,
+			want:    `{"hooks": {"pre-commit": {"actions": {"Fmt": {"name": "Fmt", "filePattern": "\\.rs$"}, "Vet": {"name": "Vet"}}}}}`,
+		},
+		{
+			name:    "Null removes value",
+			base:    `{"actions": {"Fmt": {"name": "Fmt"}, "Vet": {"name": "Vet"}}}`,
+			overlay: `{"actions": {"Fmt": null, "Lint": {"name": "Lint", "timeout": null}}}`,
+			want:    `{"actions": {"Vet": {"name": "Vet"}, "Lint": {"name": "Lint"}}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := parseDocument(t, tt.base)
+			got := mergeDocuments(base, parseDocument(t, tt.overlay))
+			if want := parseDocument(t, tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("mergeDocuments() = %v, want %v", got, want)
+			}
+			if !reflect.DeepEqual(base, parseDocument(t, tt.base)) {
+				t.Errorf("mergeDocuments() modified base document")
+			}
+		})
+	}
+}
+
+func Test_resolveExtends(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "Extends action of the same hook",
+			doc:  `{"hooks": {"pre-commit": {"actions": {"Fmt": {"name": "Fmt", "shellCmd": ["fmt"]}, "FmtRs": {"extends": "Fmt", "filePattern": "\\.rs$"}}}}}`,
+			want: `{"hooks": {"pre-commit": {"actions": {"Fmt": {"name": "Fmt", "shellCmd": ["fmt"]}, "FmtRs": {"name": "Fmt", "shellCmd": ["fmt"], "filePattern": "\\.rs$"}}}}}`,
+		},
+		{
+			name: "Extends action of another hook, transitively",
+			doc:  `{"hooks": {"a": {"actions": {"X": {"name": "X", "priority": 1}}}, "b": {"actions": {"Y": {"extends": "a/X", "name": "Y"}, "Z": {"extends": "Y", "priority": 2}}}}}`,
+			want: `{"hooks": {"a": {"actions": {"X": {"name": "X", "priority": 1}}}, "b": {"actions": {"Y": {"name": "Y", "priority": 1}, "Z": {"name": "Y", "priority": 2}}}}}`,
+		},
+		{
+			name:    "Unknown action",
+			doc:     `{"hooks": {"a": {"actions": {"X": {"extends": "Y"}}}}}`,
+			wantErr: "unknown action a/Y",
+		},
+		{
+			name:    "Cycle",
+			doc:     `{"hooks": {"a": {"actions": {"X": {"extends": "Y"}, "Y": {"extends": "X"}}}}}`,
+			wantErr: "extends cycle: a/X -> a/Y -> a/X",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := parseDocument(t, tt.doc)
+			err := resolveExtends(doc)
+			if len(tt.wantErr) > 0 {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("resolveExtends() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveExtends() error = %v", err)
+			}
+			if want := parseDocument(t, tt.want); !reflect.DeepEqual(doc, want) {
+				t.Errorf("resolveExtends() = %v, want %v", doc, want)
+			}
+		})
+	}
+}
+
+func Test_configReader_readDocument(t *testing.T) {
+	dir := t.TempDir()
+	xdg := filepath.Join(dir, "xdg")
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+
+	files := map[string]string{
+		"xdg/githooks/company.json": `{"version": 1, "hooks": {"pre-commit": {"name": "Pre-commit", "actions": {"Fmt": {"name": "Company Fmt", "priority": 1, "onFailure": "warn"}}}}}`,
+		"team/team.json":            `{"include": ["$XDG_CONFIG_HOME/githooks/company.json"], "hooks": {"pre-commit": {"actions": {"Fmt": {"name": "Team Fmt"}}}}}`,
+		"user.json":                 `{"include": ["team/team.json"], "hooks": {"pre-commit": {"actions": {"Fmt": {"priority": 2}}}}}`,
+		"cycle-a.json":              `{"include": ["cycle-b.json"]}`,
+		"cycle-b.json":              `{"include": ["cycle-a.json"]}`,
+		"stale.json":                `{"version": 2, "include": ["stale.yaml"]}`,
+		"siblings.json":             `{"include": ["team/team.json", "other.json"]}`,
+		"other.json":                `{"hooks": {"pre-commit": {"actions": {"Fmt": {"name": "Other Fmt"}, "Vet": {"name": "Vet"}}}}}`,
+		"diamond.json":              `{"include": ["other.json", "team/other.json"]}`,
+		"team/other.json":           `{"include": ["../other.json"]}`,
+		"stale.yaml":                "hooks:\n  pre-commit:\n    actions:\n      Fmt:\n        onFailure: warn\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	doc, err := newConfigReader().readDocument(filepath.Join(dir, "user.json"), nil, 0)
+	if err != nil {
+		t.Fatalf("readDocument() error = %v", err)
+	}
+	want := parseDocument(t, `{"version": 2, "hooks": {"pre-commit": {"name": "Pre-commit", "actions": {"Fmt": {"name": "Team Fmt", "priority": 2, "severity": "warning"}}}}}`)
+	got, _ := json.Marshal(doc)
+	if !reflect.DeepEqual(parseDocument(t, string(got)), want) {
+		t.Errorf("readDocument() = %s, want %v", got, want)
+	}
+
+	if _, err := newConfigReader().readDocument(filepath.Join(dir, "cycle-a.json"), nil, 0); err == nil || !strings.Contains(err.Error(), "include cycle") {
+		t.Errorf("readDocument() error = %v, want include cycle", err)
+	}
+
+	// Included files inherit the version of the including file.
+	if _, err := newConfigReader().readDocument(filepath.Join(dir, "stale.json"), nil, 0); err == nil || !strings.Contains(err.Error(), "onFailure is replaced") {
+		t.Errorf("readDocument() error = %v, want onFailure rejected", err)
+	}
+
+	// Files on the same include list must not define the same action, unless
+	// the definition comes from the same file.
+	reader := newConfigReader()
+	if _, err := reader.readDocument(filepath.Join(dir, "siblings.json"), nil, 0); err != nil {
+		t.Fatalf("readDocument() error = %v", err)
+	}
+	if len(reader.problems) != 1 || reader.problems[0].Path != "hooks.pre-commit.actions.Fmt" || reader.problems[0].File != filepath.Join(dir, "other.json") {
+		t.Errorf("readDocument() problems = %v, want Fmt defined twice", reader.problems)
+	}
+
+	reader = newConfigReader()
+	if _, err := reader.readDocument(filepath.Join(dir, "diamond.json"), nil, 0); err != nil || len(reader.problems) > 0 {
+		t.Errorf("readDocument() = %v, %v for the same file included twice", err, reader.problems)
+	}
+
+	if doc, err := newConfigReader().readDocument(filepath.Join(dir, "missing.json"), nil, 0); doc != nil || err != nil {
+		t.Errorf("readDocument() = %v, %v for missing file", doc, err)
+	}
+}
@@ -0,0 +1,176 @@
+package hooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+)
+
+const (
+	// Version of the config file format produced by the migration.
+	configVersionLatest = 2
+
+	configKeyVersion   = "version"
+	configKeyOnFailure = "onFailure"
+	configKeySeverity  = "severity"
+)
+
+// Action fields introduced in version 2 of the config file format.
+var kConfigV2ActionFields = []string{configKeySeverity, "description", "tags", "env", "workingDir"}
+
+// Translation of version 1 onFailure values into version 2 severity values.
+var kOnFailureSeverities = map[string]string{
+	configOnFailureBlock:  configSeverityError,
+	configOnFailureWarn:   configSeverityWarning,
+	configOnFailureIgnore: configSeverityInfo,
+}
+
+// Return the version of the config file format, or zero if not specified.
+func getDocumentVersion(doc configDocument) (int, error) {
+	value, ok := doc[configKeyVersion]
+	if !ok || value == nil {
+		return 0, nil
+	}
+
+	var version int64
+	var err error
+	switch v := value.(type) {
+	case json.Number:
+		version, err = v.Int64()
+	case int:
+		version = int64(v)
+	case int64:
+		version = v
+	case float64:
+		version = int64(v)
+		if float64(version) != v {
+			err = fmt.Errorf("not an integer")
+		}
+	default:
+		err = fmt.Errorf("not a number")
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("invalid version %v: %w", value, err)
+	}
+	return int(version), nil
+}
+
+// Bring the document, using the supplied version of the config file format,
+// to the latest version. Documents not specifying the version, ie. version
+// zero, are not modified.
+func upgradeDocument(doc configDocument, version int) error {
+	switch version {
+	case 0:
+		return nil
+	case 1:
+		return migrateDocument(doc)
+	case configVersionLatest:
+		return forEachActionDocument(doc, func(id string, action configDocument) error {
+			if _, ok := action[configKeyOnFailure]; ok {
+				return fmt.Errorf("action %s: %s is replaced with %s in version %d", id, configKeyOnFailure, configKeySeverity, version)
+			}
+			return nil
+		})
+	}
+	return fmt.Errorf("unsupported config file version %d", version)
+}
+
+// Rewrite the version 1 document as a version 2 document.
+func migrateDocument(doc configDocument) error {
+	err := forEachActionDocument(doc, func(id string, action configDocument) error {
+		for _, field := range kConfigV2ActionFields {
+			if _, ok := action[field]; ok {
+				return fmt.Errorf("action %s: %s requires version %d", id, field, configVersionLatest)
+			}
+		}
+
+		value, ok := action[configKeyOnFailure]
+		if !ok {
+			return nil
+		}
+		onFailure, isString := value.(string)
+		severity, ok := kOnFailureSeverities[onFailure]
+		if !isString || (!ok && len(onFailure) > 0) {
+			return fmt.Errorf("action %s: invalid %s %v", id, configKeyOnFailure, value)
+		}
+		delete(action, configKeyOnFailure)
+		if ok {
+			action[configKeySeverity] = severity
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	doc[configKeyVersion] = configVersionLatest
+	return nil
+}
+
+// Call fn for every action defined in the document, identified by the hook
+// and action IDs, in a deterministic order.
+func forEachActionDocument(doc configDocument, fn func(id string, action configDocument) error) error {
+	hooks, _ := doc["hooks"].(map[string]interface{})
+	for _, hookID := range sortedKeys(hooks) {
+		hook, _ := hooks[hookID].(map[string]interface{})
+		actions, _ := hook["actions"].(map[string]interface{})
+		for _, actionID := range sortedKeys(actions) {
+			if action, ok := actions[actionID].(map[string]interface{}); ok {
+				if err := fn(hookID+extendsSeparator+actionID, action); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Rewrite the version 1 config file as a version 2 config file, retaining
+// its format. The original content is saved aside, in a file with ".v1"
+// suffix. Files included by the config file are not migrated.
+func MigrateConfigFile(name string) error {
+	content, err := ioutil.ReadFile(name)
+	if err != nil {
+		return err
+	}
+
+	doc, err := decodeConfigDocument(name, content)
+	if err != nil {
+		return err
+	}
+
+	version, err := getDocumentVersion(doc)
+	if err != nil {
+		return err
+	}
+	if version != 1 {
+		return fmt.Errorf("%s: expected version 1, found version %d", name, version)
+	}
+
+	if err = migrateDocument(doc); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	migrated, err := encodeConfigDocument(name, doc)
+	if err != nil {
+		return err
+	}
+
+	if err = ioutil.WriteFile(name+".v"+strconv.Itoa(version), content, 0644); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(name, migrated, 0644)
+}
+
+// Return the name of the user config file, or an empty string if there is
+// none.
+func FindUserConfigFile() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return findConfigFile(home)
+}
@@ -0,0 +1,136 @@
+package hooks
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_upgradeDocument(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "Version 1 onFailure translated",
+			doc:  `{"version": 1, "hooks": {"h": {"actions": {"a": {"onFailure": "warn"}, "b": {"onFailure": "ignore"}, "c": {"onFailure": "block"}, "d": {"onFailure": ""}}}}}`,
+			want: `{"version": 2, "hooks": {"h": {"actions": {"a": {"severity": "warning"}, "b": {"severity": "info"}, "c": {"severity": "error"}, "d": {}}}}}`,
+		},
+		{
+			name: "Version 1 other fields retained",
+			doc:  `{"version": 1, "workers": 4, "hooks": {"h": {"name": "H", "actions": {"a": {"name": "A", "shellCmd": ["x"]}}}}}`,
+			want: `{"version": 2, "workers": 4, "hooks": {"h": {"name": "H", "actions": {"a": {"name": "A", "shellCmd": ["x"]}}}}}`,
+		},
+		{
+			name:    "Version 1 invalid onFailure",
+			doc:     `{"version": 1, "hooks": {"h": {"actions": {"a": {"onFailure": "sometimes"}}}}}`,
+			wantErr: true,
+		},
+		{
+			name:    "Version 1 with version 2 fields",
+			doc:     `{"version": 1, "hooks": {"h": {"actions": {"a": {"tags": ["x"]}}}}}`,
+			wantErr: true,
+		},
+		{
+			name: "Version 2 unchanged",
+			doc:  `{"version": 2, "hooks": {"h": {"actions": {"a": {"severity": "warning"}}}}}`,
+			want: `{"version": 2, "hooks": {"h": {"actions": {"a": {"severity": "warning"}}}}}`,
+		},
+		{
+			name:    "Version 2 with onFailure",
+			doc:     `{"version": 2, "hooks": {"h": {"actions": {"a": {"onFailure": "warn"}}}}}`,
+			wantErr: true,
+		},
+		{
+			name: "No version unchanged",
+			doc:  `{"hooks": {"h": {"actions": {"a": {"onFailure": "warn"}}}}}`,
+			want: `{"hooks": {"h": {"actions": {"a": {"onFailure": "warn"}}}}}`,
+		},
+		{
+			name:    "Unsupported version",
+			doc:     `{"version": 3}`,
+			wantErr: true,
+		},
+		{
+			name:    "Invalid version",
+			doc:     `{"version": 1.5}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := parseDocument(t, tt.doc)
+			version, err := getDocumentVersion(doc)
+			if err == nil {
+				err = upgradeDocument(doc, version)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("upgradeDocument() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got, _ := json.Marshal(doc)
+			if !reflect.DeepEqual(parseDocument(t, string(got)), parseDocument(t, tt.want)) {
+				t.Errorf("upgradeDocument() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_MigrateConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, ".githooks.yaml")
+	original := "version: 1\nhooks:\n  pre-commit:\n    name: Pre-commit\n    actions:\n      Vet:\n        name: Go Vet\n        onFailure: warn\n"
+	if err := os.WriteFile(name, []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := MigrateConfigFile(name); err != nil {
+		t.Fatalf("MigrateConfigFile() error = %v", err)
+	}
+
+	backup, err := os.ReadFile(name + ".v1")
+	if err != nil || string(backup) != original {
+		t.Errorf("backup = %q, %v, want %q", backup, err, original)
+	}
+
+	content, _ := os.ReadFile(name)
+	doc, err := decodeConfigDocument(name, content)
+	if err != nil {
+		t.Fatalf("decodeConfigDocument() error = %v", err)
+	}
+	got, _ := json.Marshal(doc)
+	want := `{"version": 2, "hooks": {"pre-commit": {"name": "Pre-commit", "actions": {"Vet": {"name": "Go Vet", "severity": "warning"}}}}}`
+	if !reflect.DeepEqual(parseDocument(t, string(got)), parseDocument(t, want)) {
+		t.Errorf("migrated = %s, want %s", got, want)
+	}
+
+	// The migrated file is no longer a version 1 file.
+	if err := MigrateConfigFile(name); err == nil {
+		t.Errorf("MigrateConfigFile() succeeded on a version 2 file")
+	}
+}
+
+func Test_MigrateConfigFile_keepsPlaceholders(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, ".githooks.json")
+	original := `{"version": 1, "hooks": {"pre-commit": {"actions": {"Fmt": {"shellCmd": ["gofmt", "-l", "<file>"]}}}}}`
+	if err := os.WriteFile(name, []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := MigrateConfigFile(name); err != nil {
+		t.Fatalf("MigrateConfigFile() error = %v", err)
+	}
+
+	content, _ := os.ReadFile(name)
+	if !strings.Contains(string(content), `"<file>"`) {
+		t.Errorf("migrated = %s, want the <file> placeholder preserved", content)
+	}
+}
@@ -0,0 +1,186 @@
+package hooks
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/go-git/go-billy/v5/osfs"
+)
+
+func Test_mergeConfigs(t *testing.T) {
+	stash := true
+	user := &topConfig{
+		Version:        1,
+		DefaultTimeout: "1m",
+		Hooks: map[string]*hookConfig{
+			"pre-commit": {
+				Name: "User pre-commit",
+				Actions: map[string]*actionConfig{
+					"Fmt":  {Name: "User Fmt", source: SourceUser},
+					"Lint": {Name: "User Lint", source: SourceUser},
+				},
+			},
+		},
+	}
+	repo := &topConfig{
+		Version:        1,
+		Workers:        2,
+		StashUnstaged:  &stash,
+		DefaultTimeout: "5m",
+		Hooks: map[string]*hookConfig{
+			"pre-commit": {
+				Name: "Repo pre-commit",
+				Actions: map[string]*actionConfig{
+					"Fmt":  {Name: "Repo Fmt", source: SourceRepo},
+					"Lint": {Name: "Repo Lint", Override: true, source: SourceRepo},
+					"Vet":  {Name: "Repo Vet", source: SourceRepo},
+				},
+			},
+			"pre-push": {
+				Name: "Repo pre-push",
+				Actions: map[string]*actionConfig{
+					"Test": {Name: "Repo Test", source: SourceRepo},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		trusted      bool
+		wantWorkers  int
+		wantStash    *bool
+		wantTimeout  string
+		wantLintName string
+	}{
+		{
+			name:         "Trusted",
+			trusted:      true,
+			wantWorkers:  2,
+			wantStash:    &stash,
+			wantTimeout:  "1m",
+			wantLintName: "Repo Lint",
+		},
+		{
+			name:         "Untrusted",
+			trusted:      false,
+			wantWorkers:  0,
+			wantStash:    nil,
+			wantTimeout:  "1m",
+			wantLintName: "User Lint",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged := mergeConfigs(user, repo, tt.trusted)
+
+			if merged.Workers != tt.wantWorkers || merged.StashUnstaged != tt.wantStash || merged.DefaultTimeout != tt.wantTimeout {
+				t.Errorf("settings not merged, got %+v", merged)
+			}
+			if name := merged.Hooks["pre-commit"].Name; name != "User pre-commit" {
+				t.Errorf("hook name = %s, want user name", name)
+			}
+
+			want := map[string]map[string]string{
+				"pre-commit": {"Fmt": "User Fmt", "Lint": tt.wantLintName, "Vet": "Repo Vet"},
+				"pre-push":   {"Test": "Repo Test"},
+			}
+			for ck, actions := range want {
+				got := merged.Hooks[ck].Actions
+				if len(got) != len(actions) {
+					t.Errorf("%s actions = %d, want %d", ck, len(got), len(actions))
+				}
+				for hk, name := range actions {
+					if got[hk] == nil || got[hk].Name != name {
+						t.Errorf("%s action %s = %v, want %s", ck, hk, got[hk], name)
+					}
+				}
+			}
+
+			if len(user.Hooks["pre-commit"].Actions) != 2 {
+				t.Errorf("user config modified by merge")
+			}
+			if mergeConfigs(user, nil, tt.trusted) != user {
+				t.Errorf("missing repo config not handled")
+			}
+			if onlyRepo := mergeConfigs(nil, repo, tt.trusted); onlyRepo.Workers != tt.wantWorkers || len(onlyRepo.Hooks) != 2 {
+				t.Errorf("missing user config not handled, got %+v", onlyRepo)
+			}
+		})
+	}
+}
+
+func Test_readConfigs(t *testing.T) {
+	const userConfig = `{"version": 2, "hooks": {"pre-commit": {"name": "Pre-commit", "actions": {
+		"Fmt": {"name": "Fmt", "runType": "perFile", "shellCmd": ["gofmt", "-l", "<file>"]}}}}}`
+
+	tests := []struct {
+		name             string
+		userConfig       string
+		repoConfig       string
+		wantActions      []string
+		wantUserProblems int
+		wantRepoProblems int
+	}{
+		{
+			name:        "Both valid",
+			userConfig:  userConfig,
+			repoConfig:  `{"version": 2, "hooks": {"pre-commit": {"actions": {"Vet": {"name": "Vet", "runType": "perCommit", "shellCmd": ["go", "vet"]}}}}}`,
+			wantActions: []string{"Fmt", "Vet"},
+		},
+		{
+			name:             "Invalid repo config ignored",
+			userConfig:       userConfig,
+			repoConfig:       `{"version": 2, "hooks": {"pre-commit": {"actions": {"Vet": {"name": "Vet", "runType": "never"}}}}}`,
+			wantActions:      []string{"Fmt"},
+			wantRepoProblems: 2,
+		},
+		{
+			name:             "Unnamed repo hook ignored",
+			userConfig:       userConfig,
+			repoConfig:       `{"version": 2, "hooks": {"pre-push": {"actions": {"Test": {"name": "Test", "runType": "perCommit", "shellCmd": ["go", "test"]}}}}}`,
+			wantActions:      []string{"Fmt"},
+			wantRepoProblems: 1,
+		},
+		{
+			name:             "Invalid user config",
+			userConfig:       `{"version": 2, "workers": -1}`,
+			repoConfig:       `{"version": 2, "hooks": {"pre-commit": {"name": "Pre-commit"}}}`,
+			wantUserProblems: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home, root := t.TempDir(), t.TempDir()
+			t.Setenv("HOME", home)
+			os.WriteFile(filepath.Join(home, ".githooks.json"), []byte(tt.userConfig), 0644)
+			os.WriteFile(filepath.Join(root, ".githooks.json"), []byte(tt.repoConfig), 0644)
+
+			kRepo = &fakeRepo{workDir: osfs.New(root)}
+			defer func() {
+				kRepo, kRepoTrust = nil, nil
+			}()
+
+			config, userProblems, repoProblems := readConfigs()
+			if len(userProblems) != tt.wantUserProblems || len(repoProblems) != tt.wantRepoProblems {
+				t.Fatalf("readConfigs() problems = %v, %v, want %d user and %d repo problem(s)",
+					userProblems, repoProblems, tt.wantUserProblems, tt.wantRepoProblems)
+			}
+			if tt.wantActions == nil {
+				return
+			}
+
+			got := []string{}
+			for id := range config.Hooks["pre-commit"].Actions {
+				got = append(got, id)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.wantActions) {
+				t.Errorf("readConfigs() actions = %v, want %v", got, tt.wantActions)
+			}
+		})
+	}
+}
@@ -0,0 +1,266 @@
+package hooks
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Arguments of shell commands looking like placeholders.
+var kPlaceholderPattern = regexp.MustCompile(`^<[a-z][a-z0-9-]*>$`)
+
+// Problem found in a config file.
+type ConfigError struct {
+	// Config file the problem is found in. Empty if not attributable to a
+	// single file.
+	File string
+	// Path of the offending value, eg. "hooks.pre-commit.actions.GoFmt.filePattern".
+	Path string
+	// Line and column of the offending value, starting at 1. Zero if not known.
+	Line   int
+	Column int
+	// Description of the problem.
+	Message string
+}
+
+// Create a new ConfigError at the supplied location.
+func newConfigError(location configLocation, path, message string) *ConfigError {
+	return &ConfigError{
+		File:    location.file,
+		Path:    path,
+		Line:    location.line,
+		Column:  location.column,
+		Message: message,
+	}
+}
+
+// Return the user-friendly representation of the problem, eg.
+// "/home/user/.githooks.json:12:24: hooks.pre-commit.actions.GoFmt.filePattern: ...".
+func (e *ConfigError) Error() string {
+	var out strings.Builder
+	if len(e.File) > 0 {
+		out.WriteString(e.File)
+		if e.Line > 0 {
+			fmt.Fprintf(&out, ":%d:%d", e.Line, e.Column)
+		}
+		out.WriteString(": ")
+	}
+	if len(e.Path) > 0 {
+		out.WriteString(e.Path)
+		out.WriteString(": ")
+	}
+	out.WriteString(e.Message)
+	return out.String()
+}
+
+// Sort problems by file and location.
+func sortConfigErrors(problems []*ConfigError) {
+	sort.SliceStable(problems, func(a, b int) bool {
+		pa, pb := problems[a], problems[b]
+		if pa.File != pb.File {
+			return pa.File < pb.File
+		}
+		if pa.Line != pb.Line {
+			return pa.Line < pb.Line
+		}
+		if pa.Column != pb.Column {
+			return pa.Column < pb.Column
+		}
+		return pa.Path < pb.Path
+	})
+}
+
+// Return the path of the hook.
+func getHookPath(hookID string) string {
+	return joinConfigPath("hooks", hookID)
+}
+
+// Return the path of the action, identified by the hook ID and action ID
+// separated with "/", eg. "pre-commit/GoFmt".
+func getActionPath(id string) string {
+	parts := strings.SplitN(id, extendsSeparator, 2)
+	return joinConfigPath(joinConfigPath(getHookPath(parts[0]), "actions"), parts[len(parts)-1])
+}
+
+// Collects the problems found in a single config file, along with all the
+// files it includes.
+type configValidator struct {
+	// Config file being validated.
+	file string
+	// Location of every value of the merged document.
+	locations configLocations
+	// Problems found so far.
+	problems []*ConfigError
+}
+
+// Record the problem with the value at the supplied path. Values inherited
+// from other actions, or not specified at all, are attributed to the closest
+// value that is specified.
+func (v *configValidator) report(path string, format string, args ...interface{}) {
+	location := configLocation{file: v.file}
+	for p := path; len(p) > 0; {
+		if l, ok := v.locations[p]; ok {
+			location = l
+			break
+		}
+		if i := strings.LastIndexAny(p, ".["); i >= 0 {
+			p = p[:i]
+		} else {
+			p = ""
+		}
+	}
+	v.problems = append(v.problems, newConfigError(location, path, fmt.Sprintf(format, args...)))
+}
+
+// Validate all settings, hooks and actions of the config.
+func (v *configValidator) validateConfig(config *topConfig) {
+	if config.Workers < 0 {
+		v.report("workers", "invalid number of workers %d", config.Workers)
+	}
+	if _, err := parseTimeout(config.DefaultTimeout); err != nil {
+		v.report("defaultTimeout", "invalid timeout: %v", err)
+	}
+
+	hookIDs := make([]string, 0, len(config.Hooks))
+	for hookID := range config.Hooks {
+		hookIDs = append(hookIDs, hookID)
+	}
+	sort.Strings(hookIDs)
+
+	for _, hookID := range hookIDs {
+		hook := config.Hooks[hookID]
+		if len(hookID) == 0 {
+			v.report(getHookPath(hookID), "empty hook ID")
+		}
+		if hook == nil {
+			v.report(getHookPath(hookID), "missing hook definition")
+			continue
+		}
+
+		actionIDs := make([]string, 0, len(hook.Actions))
+		for actionID := range hook.Actions {
+			actionIDs = append(actionIDs, actionID)
+		}
+		sort.Strings(actionIDs)
+
+		for _, actionID := range actionIDs {
+			v.validateAction(hookID, actionID, hook.Actions[actionID])
+		}
+	}
+}
+
+// Validate the action, and the options specific to its type.
+func (v *configValidator) validateAction(hookID, actionID string, cfg *actionConfig) {
+	path := getActionPath(hookID + extendsSeparator + actionID)
+	if len(actionID) == 0 {
+		v.report(path, "empty action ID")
+	}
+	if cfg == nil {
+		v.report(path, "missing action definition")
+		return
+	}
+
+	if len(cfg.Name) == 0 {
+		v.report(joinConfigPath(path, "name"), "missing action name")
+	}
+
+	onFailure, ok := parseSeverity(cfg.Severity)
+	if !ok {
+		v.report(joinConfigPath(path, "severity"), "unknown severity %q, expected one of: %s, %s, %s",
+			cfg.Severity, configSeverityError, configSeverityWarning, configSeverityInfo)
+	}
+
+	actionType := cfg.Type
+	if len(actionType) == 0 {
+		actionType = configActionTypeShell
+	}
+	factory, ok := kActionTypes[actionType]
+	if !ok {
+		v.report(joinConfigPath(path, "type"), "unknown action type %q, expected one of: %s", cfg.Type, strings.Join(getActionTypes(), ", "))
+		return
+	}
+
+	if actionType == configActionTypeShell {
+		v.validateShellAction(hookID, path, cfg)
+	} else if _, err := factory(actionID, cfg, onFailure); err != nil {
+		v.report(joinConfigPath(path, "options"), "%v", err)
+	}
+}
+
+// Validate the fields specific to shell actions.
+func (v *configValidator) validateShellAction(hookID, path string, cfg *actionConfig) {
+	switch cfg.RunType {
+	case configRunTypePerFile, configRunTypePerCommit, configRunTypeBatch:
+	case "":
+		v.report(joinConfigPath(path, "runType"), "missing runType, expected one of: %s, %s, %s",
+			configRunTypePerFile, configRunTypePerCommit, configRunTypeBatch)
+	default:
+		v.report(joinConfigPath(path, "runType"), "unknown runType %q, expected one of: %s, %s, %s",
+			cfg.RunType, configRunTypePerFile, configRunTypePerCommit, configRunTypeBatch)
+	}
+
+	if len(cfg.ShellCmd) == 0 || len(cfg.ShellCmd[0]) == 0 {
+		v.report(joinConfigPath(path, "shellCmd"), "missing shell command")
+	}
+	placeholders := getHookPlaceholders(hookID)
+	for i, arg := range cfg.ShellCmd {
+		if kPlaceholderPattern.MatchString(arg) && !placeholders[arg] {
+			v.report(indexConfigPath(joinConfigPath(path, "shellCmd"), i), "unknown placeholder %s for hook %s", arg, hookID)
+		}
+	}
+
+	if _, err := regexp.Compile(cfg.Pattern); err != nil {
+		v.report(joinConfigPath(path, "filePattern"), "invalid regular expression: %v", err)
+	}
+	for field, patterns := range map[string][]string{"include": cfg.Include, "exclude": cfg.Exclude} {
+		for i, pattern := range patterns {
+			if _, err := newPathMatcher(strings.TrimPrefix(pattern, patternNegatePrefix)); err != nil {
+				v.report(indexConfigPath(joinConfigPath(path, field), i), "%v", err)
+			}
+		}
+	}
+
+	if _, ok := parseFixPolicy(cfg.OnFix); !ok {
+		v.report(joinConfigPath(path, "onFix"), "unknown onFix %q, expected one of: %s, %s", cfg.OnFix, configOnFixStage, configOnFixFail)
+	}
+	if _, err := parseTimeout(cfg.Timeout); err != nil {
+		v.report(joinConfigPath(path, "timeout"), "invalid timeout: %v", err)
+	}
+	if _, ok := parseOutputMode(cfg.Output); !ok {
+		v.report(joinConfigPath(path, "output"), "unknown output %q", cfg.Output)
+	}
+	if _, err := parseWorkingDir(cfg.WorkingDir); err != nil {
+		v.report(joinConfigPath(path, "workingDir"), "%v", err)
+	}
+}
+
+// Return the set of placeholders shell commands of the hook may use.
+func getHookPlaceholders(hookID string) map[string]bool {
+	placeholders := map[string]bool{
+		placeholderSingleFile: true,
+		placeholderAllFiles:   true,
+		placeholderFileList:   true,
+		placeholderGitArgs:    true,
+	}
+
+	hookType := GetHookType(hookID)
+	for _, arg := range hookType.Args {
+		placeholders["<"+arg+">"] = true
+	}
+	if hookType.Stdin == StdinPushRefs {
+		for _, placeholder := range []string{placeholderLocalRef, placeholderLocalSha, placeholderRemoteRef, placeholderRemoteSha} {
+			placeholders[placeholder] = true
+		}
+	}
+	return placeholders
+}
+
+// Validate the user and repository config files, along with all the files
+// they include. Returns all the problems found, ordered by file and location.
+func ValidateConfig() []*ConfigError {
+	_, userProblems, repoProblems := readConfigs()
+	problems := append(userProblems, repoProblems...)
+	sortConfigErrors(problems)
+	return problems
+}
@@ -0,0 +1,188 @@
+package hooks
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_readConfigFile_problems(t *testing.T) {
+	type problem struct {
+		Path   string
+		Line   int
+		Column int
+	}
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    []problem
+	}{
+		{
+			name: "JSON",
+			file: ".githooks.json",
+			content: `{
+    "version": 2,
+    "hooks": {
+        "pre-commit": {
+            "name": "Pre-commit",
+            "actions": {
+                "Fmt": {
+                    "name": "Fmt",
+                    "runType": "perFiles",
+                    "filePattern": "*.go",
+                    "shellCmd": ["gofmt", "<msg-file>", "<file>"]
+                },
+                "Vet": {
+                    "name": "Vet",
+                    "runType": "perCommit",
+                    "shellCmd": []
+                },
+                "Vet": {
+                    "type": "builtin:nothing"
+                }
+            }
+        }
+    }
+}
+`,
+			want: []problem{
+				{"hooks.pre-commit.actions.Fmt.runType", 9, 32},
+				{"hooks.pre-commit.actions.Fmt.filePattern", 10, 36},
+				{"hooks.pre-commit.actions.Fmt.shellCmd[1]", 11, 43},
+				{"hooks.pre-commit.actions.Vet", 18, 17},
+				{"hooks.pre-commit.actions.Vet.name", 18, 24},
+				{"hooks.pre-commit.actions.Vet.type", 19, 29},
+			},
+		},
+		{
+			name: "YAML",
+			file: ".githooks.yaml",
+			content: `version: 2
+hooks:
+  pre-push:
+    name: Pre-push
+    actions:
+      Test:
+        name: Test
+        runType: perCommit
+        severity: fatal
+        exclude: ["re:("]
+        workingDir: ../up
+        shellCmd: [go, test, <local-sha>, <msg-file>]
+`,
+			want: []problem{
+				{"hooks.pre-push.actions.Test.severity", 9, 19},
+				{"hooks.pre-push.actions.Test.exclude[0]", 10, 19},
+				{"hooks.pre-push.actions.Test.workingDir", 11, 21},
+				{"hooks.pre-push.actions.Test.shellCmd[3]", 12, 43},
+			},
+		},
+		{
+			name: "TOML",
+			file: ".githooks.toml",
+			content: `version = 2
+[hooks.pre-commit]
+name = "Pre-commit"
+[hooks.pre-commit.actions.Lint]
+name = "Lint"
+runType = "batch"
+`,
+			want: []problem{
+				{"hooks.pre-commit.actions.Lint.shellCmd", 0, 0},
+			},
+		},
+		{
+			name:    "Malformed",
+			file:    ".githooks.json",
+			content: "{\n  \"version\": 2,\n  \"hooks\": {]\n}\n",
+			want: []problem{
+				{"", 3, 13},
+			},
+		},
+		{
+			name:    "Invalid value type",
+			file:    ".githooks.json",
+			content: `{"version": 2, "workers": "many"}`,
+			want: []problem{
+				{"workers", 1, 27},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(name, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			config, _, problems := readConfigFile(name, SourceUser)
+			if config != nil {
+				t.Errorf("readConfigFile() accepted invalid config")
+			}
+
+			sortConfigErrors(problems)
+			got := []problem{}
+			for _, p := range problems {
+				if p.File != name {
+					t.Errorf("problem %v reported in %s, want %s", p, p.File, name)
+				}
+				got = append(got, problem{p.Path, p.Line, p.Column})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readConfigFile() problems = %v, want %v", problems, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ConfigError_Error(t *testing.T) {
+	tests := []struct {
+		name string
+		err  ConfigError
+		want string
+	}{
+		{
+			name: "Full location",
+			err:  ConfigError{File: "a.json", Path: "hooks.x.name", Line: 3, Column: 7, Message: "missing"},
+			want: "a.json:3:7: hooks.x.name: missing",
+		},
+		{
+			name: "Unknown line",
+			err:  ConfigError{File: "a.toml", Path: "workers", Message: "invalid"},
+			want: "a.toml: workers: invalid",
+		},
+		{
+			name: "Unknown file",
+			err:  ConfigError{Path: "hooks.x.name", Message: "missing"},
+			want: "hooks.x.name: missing",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Error(); got != tt.want {
+				t.Errorf("Error() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_readConfigFile_content(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, ".githooks.yaml")
+	content := "version: 2\nhooks:\n  pre-commit:\n    name: Pre-commit\n    actions:\n      Fmt:\n        name: Fmt\n        runType: perFile\n        filePattern: \\.go$\n        shellCmd: [gofmt, -l, <file>]\n"
+	if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, got, problems := readConfigFile(name, SourceRepo)
+	if len(problems) > 0 {
+		t.Fatalf("readConfigFile() problems = %v", problems)
+	}
+	if !strings.Contains(string(got), `"<file>"`) {
+		t.Errorf("readConfigFile() content = %s, want the <file> placeholder preserved", got)
+	}
+}
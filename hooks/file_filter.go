@@ -0,0 +1,118 @@
+package hooks
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+const (
+	// Prefix denoting a path pattern expressed as a regular expression.
+	patternRegexpPrefix = "re:"
+	// Prefix negating a pattern on the include list.
+	patternNegatePrefix = "!"
+)
+
+// Selects the files an action applies to.
+type fileFilter struct {
+	// Pattern matched against base name of every file.
+	basename *regexp.Regexp
+	// Patterns matched against repository-relative path. If not empty, at least one must match.
+	include []func(string) bool
+	// Patterns matched against repository-relative path. None may match.
+	exclude []func(string) bool
+}
+
+// Create a new fileFilter.
+// The basenamePattern is a regular expression matched against the file base
+// name. Every include and exclude pattern is either a doublestar glob, or a
+// regular expression prefixed with "re:", matched against the full
+// repository-relative path. Include patterns prefixed with "!" are treated as
+// exclude patterns.
+func newFileFilter(basenamePattern string, include, exclude []string) (*fileFilter, error) {
+	basename, err := regexp.Compile(basenamePattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filePattern %q: %w", basenamePattern, err)
+	}
+
+	f := &fileFilter{basename: basename}
+
+	for _, pattern := range include {
+		if strings.HasPrefix(pattern, patternNegatePrefix) {
+			exclude = append(exclude, strings.TrimPrefix(pattern, patternNegatePrefix))
+			continue
+		}
+
+		matcher, err := newPathMatcher(pattern)
+		if err != nil {
+			return nil, err
+		}
+		f.include = append(f.include, matcher)
+	}
+
+	for _, pattern := range exclude {
+		matcher, err := newPathMatcher(pattern)
+		if err != nil {
+			return nil, err
+		}
+		f.exclude = append(f.exclude, matcher)
+	}
+
+	return f, nil
+}
+
+// Create a function matching repository-relative paths against the pattern.
+func newPathMatcher(pattern string) (func(string) bool, error) {
+	if strings.HasPrefix(pattern, patternRegexpPrefix) {
+		re, err := regexp.Compile(strings.TrimPrefix(pattern, patternRegexpPrefix))
+		if err != nil {
+			return nil, fmt.Errorf("invalid path pattern %q: %w", pattern, err)
+		}
+		return re.MatchString, nil
+	}
+
+	if !doublestar.ValidatePattern(pattern) {
+		return nil, fmt.Errorf("invalid path pattern %q", pattern)
+	}
+	return func(file string) bool {
+		match, _ := doublestar.Match(pattern, file)
+		return match
+	}, nil
+}
+
+// Check whether the file, relative to the repository root, is accepted by the filter.
+func (f *fileFilter) Match(file string) bool {
+	if !f.basename.MatchString(path.Base(file)) {
+		return false
+	}
+
+	if len(f.include) > 0 && !matchAny(f.include, file) {
+		return false
+	}
+
+	return !matchAny(f.exclude, file)
+}
+
+// Select files accepted by the filter, retaining their order.
+func (f *fileFilter) Filter(files []string) []string {
+	matches := []string{}
+	for _, file := range files {
+		if f.Match(file) {
+			matches = append(matches, file)
+		}
+	}
+	return matches
+}
+
+// Check whether any of the matchers accepts the file.
+func matchAny(matchers []func(string) bool, file string) bool {
+	for _, matcher := range matchers {
+		if matcher(file) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package hooks
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_fileFilter(t *testing.T) {
+	files := []string{
+		"main.go",
+		"README.md",
+		"services/api/server.go",
+		"services/api/server_generated.go",
+		"services/api/testdata/input.go",
+		"vendor/lib/lib.go",
+	}
+
+	type args struct {
+		basename string
+		include  []string
+		exclude  []string
+	}
+	tests := []struct {
+		name string
+		args args
+		want []string
+	}{
+		{
+			name: "Empty filter",
+			args: args{"", nil, nil},
+			want: files,
+		},
+		{
+			name: "Basename only",
+			args: args{"\\.go$", nil, nil},
+			want: []string{"main.go", "services/api/server.go", "services/api/server_generated.go", "services/api/testdata/input.go", "vendor/lib/lib.go"},
+		},
+		{
+			name: "Basename does not match directories",
+			args: args{"^services", nil, nil},
+			want: []string{},
+		},
+		{
+			name: "Include glob",
+			args: args{"", []string{"services/**/*.go"}, nil},
+			want: []string{"services/api/server.go", "services/api/server_generated.go", "services/api/testdata/input.go"},
+		},
+		{
+			name: "Include with negated glob",
+			args: args{"", []string{"services/**/*.go", "!**/testdata/**"}, nil},
+			want: []string{"services/api/server.go", "services/api/server_generated.go"},
+		},
+		{
+			name: "Exclude globs",
+			args: args{"\\.go$", nil, []string{"vendor/**", "**/*_generated.go"}},
+			want: []string{"main.go", "services/api/server.go", "services/api/testdata/input.go"},
+		},
+		{
+			name: "Regexp patterns",
+			args: args{"", []string{"re:^(services|vendor)/"}, []string{"re:/testdata/"}},
+			want: []string{"services/api/server.go", "services/api/server_generated.go", "vendor/lib/lib.go"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := newFileFilter(tt.args.basename, tt.args.include, tt.args.exclude)
+			if err != nil {
+				t.Fatalf("newFileFilter() error = %v", err)
+			}
+			if got := f.Filter(files); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Filter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_newFileFilterErrors(t *testing.T) {
+	if _, err := newFileFilter("(", nil, nil); err == nil {
+		t.Errorf("expected error for invalid filePattern")
+	}
+	if _, err := newFileFilter("", []string{"re:("}, nil); err == nil {
+		t.Errorf("expected error for invalid include regexp")
+	}
+	if _, err := newFileFilter("", nil, []string{"[a-"}); err == nil {
+		t.Errorf("expected error for invalid exclude glob")
+	}
+}
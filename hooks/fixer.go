@@ -0,0 +1,212 @@
+package hooks
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5/utils/diff"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// Policy applied when a fixer action modifies files.
+type FixPolicy int8
+
+const (
+	// Modified files are added back to the index.
+	FixStages FixPolicy = iota
+	// Modified files are reported along with the changes, and the action fails.
+	FixFails
+)
+
+const (
+	configOnFixStage = "stage"
+	configOnFixFail  = "fail"
+
+	// Number of unmodified lines reported around every change.
+	diffContextLines = 3
+)
+
+// Translate the configuration value into a FixPolicy.
+// Empty value is interpreted as FixStages. Returns false if the value is not
+// recognized.
+func parseFixPolicy(value string) (FixPolicy, bool) {
+	switch value {
+	case "", configOnFixStage:
+		return FixStages, true
+	case configOnFixFail:
+		return FixFails, true
+	}
+	return FixStages, false
+}
+
+// Return the configuration representation of the policy.
+func (p FixPolicy) String() string {
+	switch p {
+	case FixStages:
+		return configOnFixStage
+	case FixFails:
+		return configOnFixFail
+	}
+	return "unknown"
+}
+
+// Content of files captured before a fixer action is run.
+type fileSnapshot struct {
+	// Content of every captured file.
+	content map[string][]byte
+	// Files that had unstaged modifications when the snapshot was taken.
+	unstaged map[string]bool
+}
+
+// Capture the current content of the supplied files, and, for the pre-commit
+// hook, which of them have unstaged modifications. Files that cannot be read
+// are not captured.
+func takeSnapshot(ctx *RunContext, files []string) fileSnapshot {
+	snapshot := fileSnapshot{content: map[string][]byte{}, unstaged: map[string]bool{}}
+	for _, file := range files {
+		if content, err := os.ReadFile(file); err == nil {
+			snapshot.content[file] = content
+		}
+	}
+
+	if ctx.HookID == "pre-commit" && ctx.Repo != nil {
+		unstaged, err := ctx.Repo.GetUnstagedFiles(files)
+		if err != nil {
+			// Be conservative: staging any of the files could commit unwanted changes.
+			log.Println("Cannot identify unstaged changes:", err)
+			unstaged = files
+		}
+		for _, file := range unstaged {
+			snapshot.unstaged[file] = true
+		}
+	}
+	return snapshot
+}
+
+// Return the sorted list of files whose content is different from the snapshot.
+func (s fileSnapshot) modifiedFiles() []string {
+	modified := []string{}
+	for file, before := range s.content {
+		after, err := os.ReadFile(file)
+		if err != nil || !bytes.Equal(before, after) {
+			modified = append(modified, file)
+		}
+	}
+	sort.Strings(modified)
+	return modified
+}
+
+// Print the changes made to the files since the snapshot was taken.
+func (s fileSnapshot) reportChanges(header string, files []string) {
+	var report strings.Builder
+	report.WriteString(header)
+	report.WriteString("\n")
+	for _, file := range files {
+		after, _ := os.ReadFile(file)
+		report.WriteString(renderDiff(file, s.content[file], after))
+	}
+	log.Print(report.String())
+}
+
+// Apply the policy to the files modified since the snapshot was taken.
+// Fixes are staged only for the pre-commit hook; other hooks merely report
+// modified files. Files that had unstaged modifications before the action
+// was run are never staged, as these modifications would be committed along
+// with the fixes. Returns RunFailed if the policy rejects the changes, or if
+// the changes could not be staged.
+func applyFixes(ctx *RunContext, name string, policy FixPolicy, snapshot fileSnapshot) RunResult {
+	modified := snapshot.modifiedFiles()
+	if len(modified) == 0 {
+		return RunSucceeded
+	}
+
+	if policy == FixFails {
+		snapshot.reportChanges(fmt.Sprintf("%s modified %d file(s), review and stage the changes:", name, len(modified)), modified)
+		return RunFailed
+	}
+
+	if ctx.HookID != "pre-commit" {
+		log.Println(name, "modified", strings.Join(modified, ", "))
+		return RunSucceeded
+	}
+
+	clean, unstaged := []string{}, []string{}
+	for _, file := range modified {
+		if snapshot.unstaged[file] {
+			unstaged = append(unstaged, file)
+		} else {
+			clean = append(clean, file)
+		}
+	}
+
+	result := RunSucceeded
+	if len(unstaged) > 0 {
+		snapshot.reportChanges(fmt.Sprintf("%s modified %d file(s) with unstaged changes, review and stage the changes"+
+			" (or enable stashUnstaged):", name, len(unstaged)), unstaged)
+		result = RunFailed
+	}
+	if len(clean) == 0 {
+		return result
+	}
+
+	if err := ctx.Repo.StageFiles(clean); err != nil {
+		log.Println(name, "failed to stage fixes:", err)
+		return RunFailed
+	}
+	log.Println(name, "staged fixes to", strings.Join(clean, ", "))
+	return result
+}
+
+// Render the line-oriented differences between two versions of the file.
+// Unmodified lines are reported only in the vicinity of changes.
+func renderDiff(name string, before, after []byte) string {
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- a/%s\n+++ b/%s\n", name, name)
+
+	writeLines := func(prefix string, lines []string) {
+		for _, line := range lines {
+			out.WriteString(prefix)
+			out.WriteString(line)
+			out.WriteString("\n")
+		}
+	}
+
+	diffs := diff.Do(string(before), string(after))
+	for i, d := range diffs {
+		lines := strings.Split(strings.TrimSuffix(d.Text, "\n"), "\n")
+
+		switch d.Type {
+		case diffmatchpatch.DiffDelete:
+			writeLines("-", lines)
+		case diffmatchpatch.DiffInsert:
+			writeLines("+", lines)
+		case diffmatchpatch.DiffEqual:
+			leading, trailing := 0, 0
+			if i > 0 {
+				leading = min(diffContextLines, len(lines))
+			}
+			if i < len(diffs)-1 {
+				trailing = min(diffContextLines, len(lines)-leading)
+			}
+
+			writeLines(" ", lines[:leading])
+			if leading+trailing < len(lines) {
+				out.WriteString("@@\n")
+			}
+			writeLines(" ", lines[len(lines)-trailing:])
+		}
+	}
+
+	return out.String()
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
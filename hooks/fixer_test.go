@@ -0,0 +1,212 @@
+package hooks
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	billy "github.com/go-git/go-billy/v5"
+	"github.com/tomasz-wiszkowski/git-hooks/config"
+	"github.com/tomasz-wiszkowski/git-hooks/repo"
+)
+
+func Test_renderDiff(t *testing.T) {
+	tests := []struct {
+		name   string
+		before string
+		after  string
+		want   string
+	}{
+		{
+			name:   "No changes",
+			before: "one\ntwo\n",
+			after:  "one\ntwo\n",
+			want:   "--- a/f\n+++ b/f\n@@\n",
+		},
+		{
+			name:   "Modified line",
+			before: "one\ntwo\nthree\n",
+			after:  "one\n2\nthree\n",
+			want:   "--- a/f\n+++ b/f\n one\n-two\n+2\n three\n",
+		},
+		{
+			name:   "Distant context is skipped",
+			before: "1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			after:  "1\n2\n3\n4\nfive\n6\n7\n8\n9\n",
+			want:   "--- a/f\n+++ b/f\n@@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n@@\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderDiff("f", []byte(tt.before), []byte(tt.after)); got != tt.want {
+				t.Errorf("renderDiff() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// Repository recording staged files. Methods not used by the tests are not
+// implemented.
+type fakeRepo struct {
+	repo.Repo
+	workDir   billy.Filesystem
+	configDir billy.Filesystem
+	config    fakeConfigManager
+	unstaged  []string
+	staged    []string
+}
+
+func (r *fakeRepo) WorkDir() billy.Filesystem {
+	return r.workDir
+}
+
+func (r *fakeRepo) ConfigDir() billy.Filesystem {
+	return r.configDir
+}
+
+func (r *fakeRepo) GetConfigManager() config.ConfigManager {
+	if r.config == nil {
+		r.config = fakeConfigManager{}
+	}
+	return r.config
+}
+
+func (r *fakeRepo) GetUnstagedFiles(paths []string) ([]string, error) {
+	unstaged := []string{}
+	for _, path := range paths {
+		for _, u := range r.unstaged {
+			if path == u {
+				unstaged = append(unstaged, path)
+			}
+		}
+	}
+	return unstaged, nil
+}
+
+func (r *fakeRepo) StageFiles(paths []string) error {
+	r.staged = append(r.staged, paths...)
+	return nil
+}
+
+func Test_takeSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	a, b, missing := filepath.Join(dir, "a"), filepath.Join(dir, "b"), filepath.Join(dir, "missing")
+	os.WriteFile(a, []byte("a"), 0644)
+	os.WriteFile(b, []byte("b"), 0644)
+
+	tests := []struct {
+		name         string
+		hookID       string
+		wantUnstaged map[string]bool
+	}{
+		{
+			name:         "Pre-commit",
+			hookID:       "pre-commit",
+			wantUnstaged: map[string]bool{b: true},
+		},
+		{
+			name:         "Other hook",
+			hookID:       "pre-push",
+			wantUnstaged: map[string]bool{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := &RunContext{HookID: tt.hookID, Repo: &fakeRepo{unstaged: []string{b}}}
+			snapshot := takeSnapshot(ctx, []string{a, b, missing})
+
+			wantContent := map[string][]byte{a: []byte("a"), b: []byte("b")}
+			if !reflect.DeepEqual(snapshot.content, wantContent) {
+				t.Errorf("takeSnapshot() content = %v, want %v", snapshot.content, wantContent)
+			}
+			if !reflect.DeepEqual(snapshot.unstaged, tt.wantUnstaged) {
+				t.Errorf("takeSnapshot() unstaged = %v, want %v", snapshot.unstaged, tt.wantUnstaged)
+			}
+		})
+	}
+}
+
+func Test_applyFixes(t *testing.T) {
+	tests := []struct {
+		name       string
+		hookID     string
+		policy     FixPolicy
+		unstaged   []string
+		fixed      []string
+		want       RunResult
+		wantStaged []string
+	}{
+		{
+			name:   "Nothing fixed",
+			hookID: "pre-commit",
+			policy: FixStages,
+			want:   RunSucceeded,
+		},
+		{
+			name:       "Fixes staged",
+			hookID:     "pre-commit",
+			policy:     FixStages,
+			fixed:      []string{"a", "b"},
+			want:       RunSucceeded,
+			wantStaged: []string{"a", "b"},
+		},
+		{
+			name:   "Fixes rejected",
+			hookID: "pre-commit",
+			policy: FixFails,
+			fixed:  []string{"a"},
+			want:   RunFailed,
+		},
+		{
+			name:       "Files with unstaged changes not staged",
+			hookID:     "pre-commit",
+			policy:     FixStages,
+			unstaged:   []string{"b", "c"},
+			fixed:      []string{"a", "b"},
+			want:       RunFailed,
+			wantStaged: []string{"a"},
+		},
+		{
+			name:   "Fixes reported for other hooks",
+			hookID: "pre-push",
+			policy: FixStages,
+			fixed:  []string{"a"},
+			want:   RunSucceeded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := func(name string) string { return filepath.Join(dir, name) }
+			paths := func(names []string) []string {
+				result := []string{}
+				for _, name := range names {
+					result = append(result, path(name))
+				}
+				return result
+			}
+
+			files := paths([]string{"a", "b", "c"})
+			for _, file := range files {
+				os.WriteFile(file, []byte("before\n"), 0644)
+			}
+
+			r := &fakeRepo{unstaged: paths(tt.unstaged)}
+			ctx := &RunContext{HookID: tt.hookID, Repo: r}
+			snapshot := takeSnapshot(ctx, files)
+			for _, file := range paths(tt.fixed) {
+				os.WriteFile(file, []byte("after\n"), 0644)
+			}
+
+			if got := applyFixes(ctx, "Fix", tt.policy, snapshot); got != tt.want {
+				t.Errorf("applyFixes() = %v, want %v", got, tt.want)
+			}
+			if wantStaged := paths(tt.wantStaged); len(r.staged) > 0 || len(wantStaged) > 0 {
+				if !reflect.DeepEqual(r.staged, wantStaged) {
+					t.Errorf("applyFixes() staged %v, want %v", r.staged, wantStaged)
+				}
+			}
+		})
+	}
+}
@@ -6,13 +6,27 @@ type Hook interface {
 	ID() string
 	Name() string
 	Actions() []Action
+	// Return the information about the action with the supplied ID.
+	ActionInfo(actionID string) ActionInfo
 	SetConfigStore(config.ConfigManager)
 }
 
+// Information about an action, as defined in the config file.
+type ActionInfo struct {
+	// Config file the action is defined in.
+	Source ConfigSource
+	// Description of the action.
+	Description string
+	// Tags classifying the action.
+	Tags []string
+}
+
 type hook struct {
 	id      string
 	name    string
 	actions []Action
+	// Information about every action, keyed by action ID.
+	info map[string]ActionInfo
 }
 
 func (c *hook) ID() string {
@@ -27,6 +41,10 @@ func (c *hook) Actions() []Action {
 	return c.actions
 }
 
+func (c *hook) ActionInfo(actionID string) ActionInfo {
+	return c.info[actionID]
+}
+
 func (c *hook) SetConfigStore(store config.ConfigManager) {
 	for _, h := range c.Actions() {
 		h.SetConfig(store.GetConfigFor(c.ID(), h.ID()))
@@ -0,0 +1,109 @@
+package hooks
+
+// Describes the data git passes to the hook on standard input.
+type StdinProtocol int8
+
+const (
+	// Hook receives no data.
+	StdinNone StdinProtocol = iota
+	// Hook receives "<local ref> <local sha> <remote ref> <remote sha>" lines.
+	StdinPushRefs
+	// Hook receives "<old sha> <new sha> <ref>" lines.
+	StdinRefUpdates
+	// Hook receives "<old sha> <new sha> [<extra>]" lines.
+	StdinRewrites
+)
+
+// Describes the set of files actions of the hook operate on.
+type FileSet int8
+
+const (
+	// Files added or modified by the most recent commit.
+	FileSetLastCommit FileSet = iota
+	// Files added or modified in the index.
+	FileSetStaged
+	// Files added or modified by the commits being pushed.
+	FileSetPushed
+)
+
+// Names of hook arguments. Every argument is available to actions as
+// a placeholder, eg. <msg-file>.
+const (
+	argMsgFile      = "msg-file"
+	argCommitSource = "commit-source"
+	argCommitSha    = "commit-sha"
+	argRemoteName   = "remote-name"
+	argRemoteUrl    = "remote-url"
+)
+
+// Describes the interface of a standard git hook.
+type HookType struct {
+	// Names of the positional arguments passed by git, in order. Trailing
+	// arguments may be omitted by git.
+	Args []string
+	// Data passed by git on standard input.
+	Stdin StdinProtocol
+	// Files the actions operate on.
+	Files FileSet
+}
+
+// Registry of standard git hooks, as documented by githooks(5).
+var kHookTypes = map[string]*HookType{
+	"applypatch-msg":        {Args: []string{argMsgFile}, Files: FileSetStaged},
+	"pre-applypatch":        {Files: FileSetStaged},
+	"post-applypatch":       {},
+	"pre-commit":            {Files: FileSetStaged},
+	"pre-merge-commit":      {Files: FileSetStaged},
+	"prepare-commit-msg":    {Args: []string{argMsgFile, argCommitSource, argCommitSha}, Files: FileSetStaged},
+	"commit-msg":            {Args: []string{argMsgFile}, Files: FileSetStaged},
+	"post-commit":           {},
+	"pre-rebase":            {Args: []string{"upstream", "rebased-branch"}},
+	"post-checkout":         {Args: []string{"previous-head", "new-head", "branch-checkout"}},
+	"post-merge":            {Args: []string{"squash-merge"}},
+	"pre-push":              {Args: []string{argRemoteName, argRemoteUrl}, Stdin: StdinPushRefs, Files: FileSetPushed},
+	"pre-receive":           {Stdin: StdinRefUpdates},
+	"update":                {Args: []string{"ref-name", "old-sha", "new-sha"}},
+	"proc-receive":          {},
+	"post-receive":          {Stdin: StdinRefUpdates},
+	"post-update":           {},
+	"reference-transaction": {Args: []string{"transaction-state"}, Stdin: StdinRefUpdates},
+	"push-to-checkout":      {Args: []string{"new-sha"}},
+	"pre-auto-gc":           {},
+	"post-rewrite":          {Args: []string{"rewrite-command"}, Stdin: StdinRewrites},
+	"sendemail-validate":    {Args: []string{"patch-file"}},
+	"post-index-change":     {Args: []string{"workdir-updated", "skip-worktree-updated"}},
+}
+
+// Retrieve the description of the hook. Hooks not known to git are assumed
+// to take no arguments, and operate on files modified by the most recent
+// commit.
+func GetHookType(id string) *HookType {
+	if t, ok := kHookTypes[id]; ok {
+		return t
+	}
+	return &HookType{}
+}
+
+// Populate substitutions for the named arguments of the hook.
+// Every placeholder expands to the corresponding argument, or to nothing if
+// the argument was not supplied by git.
+func addHookArgSubstitutions(substitutions map[string]interface{}, hookID string, args []string) {
+	for i, name := range GetHookType(hookID).Args {
+		value := []string{}
+		if i < len(args) {
+			value = args[i : i+1]
+		}
+		substitutions["<"+name+">"] = value
+	}
+}
+
+// Retrieve the named argument of the hook. Returns false if the hook does not
+// take such argument, or if the argument was not supplied by git.
+func getHookArg(hookID string, args []string, name string) (string, bool) {
+	for i, arg := range GetHookType(hookID).Args {
+		if arg == name && i < len(args) {
+			return args[i], true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,51 @@
+package hooks
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_addHookArgSubstitutions(t *testing.T) {
+	tests := []struct {
+		name   string
+		hookID string
+		args   []string
+		want   map[string]interface{}
+	}{
+		{
+			name:   "Unknown hook",
+			hookID: "custom-hook",
+			args:   []string{"one"},
+			want:   map[string]interface{}{},
+		},
+		{
+			name:   "All arguments",
+			hookID: "prepare-commit-msg",
+			args:   []string{".git/COMMIT_EDITMSG", "commit", "HEAD"},
+			want: map[string]interface{}{
+				"<msg-file>":      []string{".git/COMMIT_EDITMSG"},
+				"<commit-source>": []string{"commit"},
+				"<commit-sha>":    []string{"HEAD"},
+			},
+		},
+		{
+			name:   "Omitted arguments",
+			hookID: "prepare-commit-msg",
+			args:   []string{".git/COMMIT_EDITMSG"},
+			want: map[string]interface{}{
+				"<msg-file>":      []string{".git/COMMIT_EDITMSG"},
+				"<commit-source>": []string{},
+				"<commit-sha>":    []string{},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := map[string]interface{}{}
+			addHookArgSubstitutions(got, tt.hookID, tt.args)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("addHookArgSubstitutions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
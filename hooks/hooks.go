@@ -1,23 +1,70 @@
 package hooks
 
-import "github.com/tomasz-wiszkowski/git-hooks/config"
+import (
+	"runtime"
+	"time"
+
+	"github.com/tomasz-wiszkowski/git-hooks/config"
+	"github.com/tomasz-wiszkowski/git-hooks/repo"
+)
 
 // A map of all known and user-defined hooks and their corresponding actions.
 // The key is the hook name, and the value is the corresponding Hook definition.
 type Hooks map[string]Hook
 
+// Global settings, applicable to all hooks.
+type Settings struct {
+	// Maximum number of commands executed concurrently.
+	Workers int
+	// Whether unstaged changes should be saved aside while pre-commit actions are run.
+	StashUnstaged bool
+	// Time permitted for every action to complete, unless the action specifies
+	// its own limit. Zero means no limit.
+	DefaultTimeout time.Duration
+}
+
 var kKnownHooks Hooks = nil
+var kSettings *Settings = nil
+var kRepo repo.Repo = nil
+
+// Create Settings object populated with default values.
+func newDefaultSettings() *Settings {
+	return &Settings{
+		Workers: runtime.NumCPU(),
+	}
+}
+
+// Load the user-defined hooks and settings, unless these have already been
+// loaded.
+func loadHooksAndSettings() {
+	if kKnownHooks == nil {
+		kKnownHooks, kSettings = loadConfigFile()
+	}
+}
+
+// Specify the repository the hooks are run in. The repository config file is
+// looked up at the root of its worktree. Must be called before the hooks are
+// loaded.
+func SetRepo(r repo.Repo) {
+	kRepo = r
+}
 
 // Retrieve the map of user-defined hooks.
 // Upon first call the function will attempt to load user-defined hooks from
-// the ~/.githooks.json config file.
+// the ~/.githooks.json config file, and the repository config file.
 func GetHooks() Hooks {
-	if kKnownHooks == nil {
-		kKnownHooks = loadConfigFile()
-	}
+	loadHooksAndSettings()
 	return kKnownHooks
 }
 
+// Retrieve the global settings.
+// Upon first call the function will attempt to load user-defined settings from
+// the ~/.githooks.json config file, and the repository config file.
+func GetSettings() *Settings {
+	loadHooksAndSettings()
+	return kSettings
+}
+
 // Specify the configuration store persisting action configuration relevant to
 // the current context (typically the current git repository).
 func (h Hooks) SetConfigStore(s config.ConfigManager) {
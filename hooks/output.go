@@ -0,0 +1,151 @@
+package hooks
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"sync"
+
+	"golang.org/x/term"
+)
+
+// Controls how the output of commands is presented to the user.
+type OutputMode int8
+
+const (
+	// Output is shown only if the command fails.
+	OutputBuffer OutputMode = iota
+	// Output is passed through as soon as it is produced.
+	OutputStream
+	// Output is never shown.
+	OutputQuiet
+)
+
+const (
+	configOutputBuffer = "buffer"
+	configOutputStream = "stream"
+	configOutputQuiet  = "quiet"
+)
+
+// Translate the configuration value into an OutputMode.
+// Empty value is interpreted as OutputBuffer. Returns false if the value is
+// not recognized.
+func parseOutputMode(value string) (OutputMode, bool) {
+	switch value {
+	case "", configOutputBuffer:
+		return OutputBuffer, true
+	case configOutputStream:
+		return OutputStream, true
+	case configOutputQuiet:
+		return OutputQuiet, true
+	}
+	return OutputBuffer, false
+}
+
+// Return the configuration representation of the mode.
+func (m OutputMode) String() string {
+	switch m {
+	case OutputBuffer:
+		return configOutputBuffer
+	case OutputStream:
+		return configOutputStream
+	case OutputQuiet:
+		return configOutputQuiet
+	}
+	return "unknown"
+}
+
+// Serializes writes to the terminal, so that lines emitted by concurrently
+// run commands do not interleave.
+var kOutputLock sync.Mutex
+
+// ANSI colors used to tell apart output of different actions.
+var kPrefixColors = []int{32, 33, 34, 35, 36}
+
+// Check whether the output written to the file can be colored.
+// Honors the NO_COLOR convention (https://no-color.org).
+func supportsColor(f *os.File) bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	if os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// Build the prefix identifying lines produced by the named action.
+// The prefix is colored if the destination supports colors.
+func outputPrefix(name string, dst *os.File) string {
+	if !supportsColor(dst) {
+		return fmt.Sprintf("[%s] ", name)
+	}
+
+	hash := fnv.New32a()
+	hash.Write([]byte(name))
+	color := kPrefixColors[hash.Sum32()%uint32(len(kPrefixColors))]
+	return fmt.Sprintf("\x1b[%dm[%s]\x1b[0m ", color, name)
+}
+
+// Writer passing complete lines to the destination, prefixing each of them.
+type prefixWriter struct {
+	dst    io.Writer
+	prefix string
+	// Incomplete line, awaiting the line terminator.
+	pending []byte
+}
+
+// Create a new prefixWriter.
+func newPrefixWriter(dst io.Writer, prefix string) *prefixWriter {
+	return &prefixWriter{dst: dst, prefix: prefix}
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.pending = append(w.pending, p...)
+
+	for {
+		end := bytes.IndexByte(w.pending, '\n')
+		if end < 0 {
+			break
+		}
+		if err := w.writeLine(w.pending[:end+1]); err != nil {
+			return 0, err
+		}
+		w.pending = w.pending[end+1:]
+	}
+
+	return len(p), nil
+}
+
+// Pass the remaining incomplete line to the destination, if any.
+func (w *prefixWriter) Flush() error {
+	if len(w.pending) == 0 {
+		return nil
+	}
+	line := append(w.pending, '\n')
+	w.pending = nil
+	return w.writeLine(line)
+}
+
+func (w *prefixWriter) writeLine(line []byte) error {
+	kOutputLock.Lock()
+	defer kOutputLock.Unlock()
+
+	_, err := w.dst.Write(append([]byte(w.prefix), line...))
+	return err
+}
+
+// Print the captured output to the destination as a single block, prefixing
+// every line.
+func printPrefixed(dst io.Writer, prefix string, content []byte) {
+	var block bytes.Buffer
+	w := newPrefixWriter(&block, prefix)
+	w.Write(content)
+	w.Flush()
+
+	kOutputLock.Lock()
+	defer kOutputLock.Unlock()
+	dst.Write(block.Bytes())
+}
@@ -0,0 +1,53 @@
+package hooks
+
+import (
+	"bytes"
+	"testing"
+)
+
+func Test_prefixWriter(t *testing.T) {
+	tests := []struct {
+		name   string
+		writes []string
+		want   string
+	}{
+		{
+			name:   "No output",
+			writes: []string{},
+			want:   "",
+		},
+		{
+			name:   "Complete lines",
+			writes: []string{"one\ntwo\n"},
+			want:   "> one\n> two\n",
+		},
+		{
+			name:   "Lines split across writes",
+			writes: []string{"o", "ne\nt", "wo\n"},
+			want:   "> one\n> two\n",
+		},
+		{
+			name:   "Incomplete last line",
+			writes: []string{"one\ntwo"},
+			want:   "> one\n> two\n",
+		},
+		{
+			name:   "Empty lines",
+			writes: []string{"\n\n"},
+			want:   "> \n> \n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			w := newPrefixWriter(&out, "> ")
+			for _, s := range tt.writes {
+				w.Write([]byte(s))
+			}
+			w.Flush()
+			if got := out.String(); got != tt.want {
+				t.Errorf("prefixWriter produced %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package hooks
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A single ref update, as reported by git to the pre-push hook on stdin.
+type PushRef struct {
+	// Name of the local ref being pushed, eg. refs/heads/main.
+	LocalRef string
+	// SHA of the local ref. All zeros if the remote ref is being deleted.
+	LocalSha string
+	// Name of the remote ref being updated.
+	RemoteRef string
+	// SHA of the remote ref. All zeros if the remote ref does not exist yet.
+	RemoteSha string
+}
+
+// Check whether the supplied sha is the all-zero sha, used by git to denote
+// a ref that does not exist.
+func IsZeroSha(sha string) bool {
+	return len(sha) > 0 && strings.Trim(sha, "0") == ""
+}
+
+// Return whether the push deletes the remote ref.
+func (r PushRef) IsDelete() bool {
+	return IsZeroSha(r.LocalSha)
+}
+
+// Return whether the push creates the remote ref.
+func (r PushRef) IsNew() bool {
+	return IsZeroSha(r.RemoteSha)
+}
+
+// Parse the pre-push protocol, where every line takes the form of
+// "<local ref> <local sha> <remote ref> <remote sha>".
+// Empty lines are ignored. Returns an error if any line is malformed.
+func ParsePushRefs(in io.Reader) ([]PushRef, error) {
+	refs := []PushRef{}
+	scanner := bufio.NewScanner(in)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if len(text) == 0 {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("malformed ref update on line %d: %q", line, text)
+		}
+
+		refs = append(refs, PushRef{
+			LocalRef:  fields[0],
+			LocalSha:  fields[1],
+			RemoteRef: fields[2],
+			RemoteSha: fields[3],
+		})
+	}
+
+	return refs, scanner.Err()
+}
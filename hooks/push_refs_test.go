@@ -0,0 +1,71 @@
+package hooks
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_ParsePushRefs(t *testing.T) {
+	const sha1 = "1111111111111111111111111111111111111111"
+	const sha2 = "2222222222222222222222222222222222222222"
+	const zero = "0000000000000000000000000000000000000000"
+
+	tests := []struct {
+		name    string
+		input   string
+		want    []PushRef
+		wantErr bool
+	}{
+		{
+			name:  "No input",
+			input: "",
+			want:  []PushRef{},
+		},
+		{
+			name:  "Single update",
+			input: "refs/heads/main " + sha1 + " refs/heads/main " + sha2 + "\n",
+			want:  []PushRef{{"refs/heads/main", sha1, "refs/heads/main", sha2}},
+		},
+		{
+			name: "Multiple updates with empty lines",
+			input: "refs/heads/a " + sha1 + " refs/heads/a " + zero + "\n\n" +
+				"(delete) " + zero + " refs/heads/b " + sha2 + "\n",
+			want: []PushRef{
+				{"refs/heads/a", sha1, "refs/heads/a", zero},
+				{"(delete)", zero, "refs/heads/b", sha2},
+			},
+		},
+		{
+			name:    "Malformed update",
+			input:   "refs/heads/main " + sha1 + "\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePushRefs(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePushRefs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParsePushRefs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_PushRefKind(t *testing.T) {
+	const sha = "1111111111111111111111111111111111111111"
+	const zero = "0000000000000000000000000000000000000000"
+
+	if r := (PushRef{"a", sha, "a", zero}); !r.IsNew() || r.IsDelete() {
+		t.Errorf("expected %v to be a new ref", r)
+	}
+	if r := (PushRef{"a", zero, "a", sha}); r.IsNew() || !r.IsDelete() {
+		t.Errorf("expected %v to be a deletion", r)
+	}
+	if IsZeroSha("") {
+		t.Errorf("expected empty sha not to be considered zero")
+	}
+}
@@ -0,0 +1,26 @@
+package hooks
+
+import (
+	"time"
+
+	"github.com/tomasz-wiszkowski/git-hooks/repo"
+)
+
+// Describes the environment in which actions are run.
+type RunContext struct {
+	// ID of the hook being run, eg. pre-commit.
+	HookID string
+	// Repository the hook is run for.
+	Repo repo.Repo
+	// Files relevant to the hook, relative to the working directory root.
+	Files []string
+	// Arguments passed to the hook by git.
+	Args []string
+	// Refs being pushed. Only populated for the pre-push hook.
+	PushRefs []PushRef
+	// Pool of workers used to execute commands concurrently.
+	Workers *WorkerPool
+	// Time permitted for every action to complete, unless the action specifies
+	// its own limit. Zero means no limit.
+	DefaultTimeout time.Duration
+}
@@ -0,0 +1,92 @@
+package hooks
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"sync"
+)
+
+// Run the supplied actions in the order of their priority.
+// Actions sharing the same priority are run concurrently, and every priority
+// group completes before the next one is started. Within every group, actions
+// modifying files in place run first, one at a time, so that they don't
+// rewrite files other actions read or modify. Returns the result of every
+// action, in the order in which the actions were supplied. Actions that panic
+// are reported as failed.
+func RunActions(actions []Action, ctx *RunContext) []RunResult {
+	groups := map[int32][]int{}
+	priorities := []int32{}
+	for i, a := range actions {
+		if _, ok := groups[a.Priority()]; !ok {
+			priorities = append(priorities, a.Priority())
+		}
+		groups[a.Priority()] = append(groups[a.Priority()], i)
+	}
+	sort.Slice(priorities, func(a, b int) bool { return priorities[a] < priorities[b] })
+
+	results := make([]RunResult, len(actions))
+	for _, priority := range priorities {
+		concurrent := []int{}
+		for _, i := range groups[priority] {
+			if modifiesFiles(actions[i]) {
+				results[i] = runAction(actions[i], ctx)
+			} else {
+				concurrent = append(concurrent, i)
+			}
+		}
+
+		var wg sync.WaitGroup
+		for _, i := range concurrent {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				results[i] = runAction(actions[i], ctx)
+			}(i)
+		}
+		wg.Wait()
+	}
+
+	return results
+}
+
+// Run the action, reporting a panic as a failure. This keeps a single faulty
+// action from terminating the hook before the working directory is restored.
+func runAction(a Action, ctx *RunContext) (result RunResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Println(a.Name(), "crashed:", r)
+			result = RunFailed
+		}
+	}()
+	return a.Run(ctx)
+}
+
+// Print the result of every selected action, along with the failure policy
+// applied to it. Returns whether any failure rejects the git operation.
+func SummarizeResults(out io.Writer, actions []Action, results []RunResult) bool {
+	failed := false
+	for i, a := range actions {
+		result := results[i]
+		if !a.IsSelected() {
+			continue
+		}
+
+		if !result.IsFailure() {
+			fmt.Fprintf(out, "%-12s %s\n", result, a.Name())
+			continue
+		}
+
+		switch a.OnFailure() {
+		case FailureBlocks:
+			fmt.Fprintf(out, "%-12s %s\n", result, a.Name())
+			failed = true
+		case FailureWarns:
+			fmt.Fprintf(out, "%-12s %s (warning only)\n", result, a.Name())
+		case FailureIgnored:
+			fmt.Fprintf(out, "%-12s %s (ignored)\n", result, a.Name())
+		}
+	}
+	return failed
+}
@@ -0,0 +1,197 @@
+package hooks
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Log of action start and end events, shared by concurrently run actions.
+type eventLog struct {
+	lock   sync.Mutex
+	events []string
+}
+
+func (l *eventLog) add(event string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.events = append(l.events, event)
+}
+
+// Return the position of the event in the log, or -1 if not found.
+func (l *eventLog) index(event string) int {
+	for i, e := range l.events {
+		if e == event {
+			return i
+		}
+	}
+	return -1
+}
+
+// Action recording when it starts and ends.
+type recordingAction struct {
+	actionBase
+	fixer  bool
+	result RunResult
+	log    *eventLog
+}
+
+func (a *recordingAction) IsAvailable() bool {
+	return true
+}
+
+func (a *recordingAction) ModifiesFiles() bool {
+	return a.fixer
+}
+
+func (a *recordingAction) Run(ctx *RunContext) RunResult {
+	a.log.add("start " + a.ID())
+	time.Sleep(20 * time.Millisecond)
+	a.log.add("end " + a.ID())
+	return a.result
+}
+
+func Test_RunActions(t *testing.T) {
+	type action struct {
+		id       string
+		priority int32
+		fixer    bool
+		result   RunResult
+	}
+	tests := []struct {
+		name    string
+		actions []action
+		// Pairs of actions, where the first must end before the second starts.
+		sequential [][2]string
+		// Pairs of actions, where each must start before the other ends.
+		concurrent [][2]string
+	}{
+		{
+			name: "Priorities run in order",
+			actions: []action{
+				{"c", 2, false, RunSucceeded},
+				{"a", 0, false, RunFailed},
+				{"b", 1, false, RunSkipped},
+			},
+			sequential: [][2]string{{"a", "b"}, {"b", "c"}},
+		},
+		{
+			name: "Same priority runs concurrently",
+			actions: []action{
+				{"a", 0, false, RunSucceeded},
+				{"b", 0, false, RunFailed},
+				{"c", 1, false, RunSucceeded},
+			},
+			sequential: [][2]string{{"a", "c"}, {"b", "c"}},
+			concurrent: [][2]string{{"a", "b"}},
+		},
+		{
+			name: "Fixers run first, one at a time",
+			actions: []action{
+				{"check1", 0, false, RunSucceeded},
+				{"fix1", 0, true, RunSucceeded},
+				{"check2", 0, false, RunSucceeded},
+				{"fix2", 0, true, RunFailed},
+			},
+			sequential: [][2]string{{"fix1", "fix2"}, {"fix2", "check1"}, {"fix2", "check2"}},
+			concurrent: [][2]string{{"check1", "check2"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := &eventLog{}
+			actions := []Action{}
+			want := []RunResult{}
+			for _, a := range tt.actions {
+				actions = append(actions, &recordingAction{
+					actionBase: newActionBase(a.id, a.id, a.priority, FailureBlocks),
+					fixer:      a.fixer,
+					result:     a.result,
+					log:        log,
+				})
+				want = append(want, a.result)
+			}
+
+			got := RunActions(actions, &RunContext{})
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("RunActions() = %v, want %v", got, want)
+			}
+
+			for _, pair := range tt.sequential {
+				if log.index("end "+pair[0]) > log.index("start "+pair[1]) {
+					t.Errorf("%s did not end before %s started: %v", pair[0], pair[1], log.events)
+				}
+			}
+			for _, pair := range tt.concurrent {
+				if log.index("start "+pair[0]) > log.index("end "+pair[1]) || log.index("start "+pair[1]) > log.index("end "+pair[0]) {
+					t.Errorf("%s and %s did not run concurrently: %v", pair[0], pair[1], log.events)
+				}
+			}
+		})
+	}
+}
+
+// Action that panics when run.
+type crashingAction struct {
+	actionBase
+}
+
+func (a *crashingAction) IsAvailable() bool {
+	return true
+}
+
+func (a *crashingAction) Run(ctx *RunContext) RunResult {
+	panic("crashed")
+}
+
+func Test_RunActions_crash(t *testing.T) {
+	log := &eventLog{}
+	actions := []Action{
+		&crashingAction{newActionBase("crash", "crash", 0, FailureBlocks)},
+		&recordingAction{actionBase: newActionBase("next", "next", 1, FailureBlocks), result: RunSucceeded, log: log},
+	}
+
+	got := RunActions(actions, &RunContext{})
+	if want := []RunResult{RunFailed, RunSucceeded}; !reflect.DeepEqual(got, want) {
+		t.Errorf("RunActions() = %v, want %v", got, want)
+	}
+}
+
+func Test_SummarizeResults(t *testing.T) {
+	tests := []struct {
+		name       string
+		onFailure  FailurePolicy
+		selected   bool
+		result     RunResult
+		want       string
+		wantFailed bool
+	}{
+		{"Success", FailureBlocks, true, RunSucceeded, "ok           a\n", false},
+		{"Skipped", FailureBlocks, true, RunSkipped, "skipped      a\n", false},
+		{"Unavailable", FailureBlocks, true, RunUnavailable, "unavailable  a\n", false},
+		{"Failure blocks", FailureBlocks, true, RunFailed, "failed       a\n", true},
+		{"Failure warns", FailureWarns, true, RunFailed, "failed       a (warning only)\n", false},
+		{"Failure ignored", FailureIgnored, true, RunFailed, "failed       a (ignored)\n", false},
+		{"Timeout blocks", FailureBlocks, true, RunTimedOut, "timed out    a\n", true},
+		{"Timeout warns", FailureWarns, true, RunTimedOut, "timed out    a (warning only)\n", false},
+		{"Timeout ignored", FailureIgnored, true, RunTimedOut, "timed out    a (ignored)\n", false},
+		{"Unselected failure", FailureBlocks, false, RunFailed, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &recordingAction{actionBase: newActionBase("a", "a", 0, tt.onFailure)}
+			a.selected = tt.selected
+
+			var out strings.Builder
+			failed := SummarizeResults(&out, []Action{a}, []RunResult{tt.result})
+			if got := out.String(); got != tt.want {
+				t.Errorf("SummarizeResults() printed %q, want %q", got, tt.want)
+			}
+			if failed != tt.wantFailed {
+				t.Errorf("SummarizeResults() = %v, want %v", failed, tt.wantFailed)
+			}
+		})
+	}
+}
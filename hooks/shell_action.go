@@ -1,12 +1,15 @@
 package hooks
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"log"
-	"path"
-	"regexp"
+	"os"
+	"sync"
+	"time"
 
-	"github.com/tomasz-wiszkowski/git-hooks/check"
 	"github.com/tomasz-wiszkowski/git-hooks/config"
 )
 
@@ -17,6 +20,8 @@ const (
 	runPerCommit RunType = iota
 	// Run once per file.
 	runPerFile
+	// Run once per group of files, passing as many files as possible to every invocation.
+	runBatch
 )
 
 const (
@@ -24,6 +29,16 @@ const (
 	keyEnabled = "enabled"
 	// Configuration key controlling the substitute command path.
 	keyCommand = "cmd"
+	// Configuration key controlling the failure policy.
+	keySeverity = "severity"
+	// Legacy configuration key controlling the failure policy.
+	keyOnFailure = "onFailure"
+	// Configuration key controlling the policy applied to files modified by fixers.
+	keyOnFix = "onFix"
+	// Configuration key controlling the time permitted for the hook to complete.
+	keyTimeout = "timeout"
+	// Configuration key controlling how the output of commands is presented.
+	keyOutput = "output"
 
 	// Value indicating boolean true
 	valueTrue = "true"
@@ -31,44 +46,60 @@ const (
 	// Placeholder for a single matching file name.
 	placeholderSingleFile = "<file>"
 
+	// Placeholder for all matching file names.
+	placeholderAllFiles = "<files>"
+
+	// Placeholder for the name of a temporary file listing all matching file names, one per line.
+	placeholderFileList = "<files-list>"
+
 	// Placeholder for hook arguments, as supplied by Git.
 	placeholderGitArgs = "<args>"
+
+	// Placeholders for the refs being pushed, as supplied by Git to pre-push hook.
+	// Each placeholder expands to one value per ref being pushed.
+	placeholderLocalRef  = "<local-ref>"
+	placeholderLocalSha  = "<local-sha>"
+	placeholderRemoteRef = "<remote-ref>"
+	placeholderRemoteSha = "<remote-sha>"
 )
 
 // shellAction is a convenient do-it-all class that can be instantiated to execute tools from shell.
 type shellAction struct {
-	// Unique ID of the hook. Not enforced.
-	id string
-	// Human-readable name of the hook.
-	name string
-	// Execution prioirty.
-	priority int32
-	// Regexp pattern for file matching. This hook will execute only if appropriate matches are found.
-	filePattern *regexp.Regexp
+	actionBase
+	// Filter selecting files. This hook will execute only if appropriate matches are found.
+	filter *fileFilter
 	// Shell command and arguments.
 	shellCommand []string
 	// Execution style, eg. once per file or once per commit.
 	runType RunType
-	// Whether the hook is selected to be run.
-	selected bool
+	// Whether the hook modifies files in place.
+	fixer bool
+	// Policy applied to files modified by the fixer.
+	onFix FixPolicy
+	// Time permitted for the hook to complete. Zero means the default limit applies.
+	timeout time.Duration
+	// Controls how the output of commands is presented.
+	output OutputMode
+	// Environment variables added to commands, in the KEY=VALUE form.
+	env []string
+	// Directory commands are run in, relative to the worktree root. Empty means the root.
+	workingDir string
 	// Whether the hook is available, eg. appropriate tools are installed. This is controlled by the user of the hook.
 	available bool
-	// Related configuration section where additional metadata may be stored.
-	config config.Config
 }
 
 // Create a new shellAction object from the supplied pieces.
-func newShellAction(id, name string, priority int32, filePattern string, shellCmd []string, runType RunType) *shellAction {
+func newShellAction(id, name string, priority int32, onFailure FailurePolicy, filter *fileFilter, shellCmd []string, runType RunType, fixer bool, onFix FixPolicy, timeout time.Duration, output OutputMode) *shellAction {
 	hb := &shellAction{
-		id:           id,
-		name:         name,
-		priority:     priority,
-		filePattern:  regexp.MustCompile(filePattern),
+		actionBase:   newActionBase(id, name, priority, onFailure),
+		filter:       filter,
 		available:    false,
 		shellCommand: shellCmd,
-		selected:     false,
 		runType:      runType,
-		config:       nil,
+		fixer:        fixer,
+		onFix:        onFix,
+		timeout:      timeout,
+		output:       output,
 	}
 
 	return hb
@@ -83,63 +114,152 @@ func (h *shellAction) setShellCmd(cmd string) {
 	}
 }
 
-// Return the unique ID of this hook.
-func (h *shellAction) ID() string {
-	return h.id
-}
-
-// Return the human readable name of the hook.
-func (h *shellAction) Name() string {
-	return h.name
-}
-
-// Return priority of the hook. Lower number = higher priority.
-func (h *shellAction) Priority() int32 {
-	return h.priority
-}
-
-// Execute an action associated with the hook on the supplied list of files.
-// Each file is matched against the previously supplied filePattern.
+// Execute an action associated with the hook on the list of files supplied with the context.
+// Each file is matched against the previously supplied file filter.
 // Performs no operation if the hook is not selected, or if the corresponding command does not exist.
-func (h *shellAction) Run(files []string, args []string) {
+// Returns RunFailed if any of the invoked commands failed.
+func (h *shellAction) Run(ctx *RunContext) RunResult {
 	if !h.IsSelected() {
-		return
+		return RunSkipped
 	}
 	if !h.IsAvailable() {
 		fmt.Println("Cannot run", h.Name(), "- missing command", h.shellCommand[0])
-		return
+		return RunUnavailable
 	}
 
 	substitutions := map[string]interface{}{
-		placeholderGitArgs: args,
+		placeholderGitArgs: ctx.Args,
+	}
+	addHookArgSubstitutions(substitutions, ctx.HookID, ctx.Args)
+	addPushRefSubstitutions(substitutions, ctx.PushRefs)
+
+	type invocation struct {
+		file string
+		cmd  []string
 	}
 
-	for _, file := range files {
-		base := path.Base(file)
+	matches := h.filter.Filter(ctx.Files)
 
-		if !h.filePattern.MatchString(base) {
-			continue
+	if len(matches) == 0 {
+		return RunSkipped
+	}
+
+	// Commands see file names relative to their working directory.
+	files := matches
+	if len(h.workingDir) > 0 {
+		files = relativePaths(matches, h.workingDir)
+	}
+
+	substitutions[placeholderAllFiles] = files
+	if countPlaceholder(h.shellCommand, placeholderFileList) > 0 {
+		listFile, err := writeFileList(files)
+		if err != nil {
+			log.Println("Cannot run", h.Name(), "- failed to write list of files:", err)
+			return RunFailed
+		}
+		defer os.Remove(listFile)
+		substitutions[placeholderFileList] = listFile
+	}
+
+	var snapshot fileSnapshot
+	if h.fixer {
+		snapshot = takeSnapshot(ctx, matches)
+	}
+
+	invocations := []invocation{}
+	switch h.runType {
+	case runPerCommit:
+		substitutions[placeholderSingleFile] = files[0]
+		invocations = append(invocations, invocation{files[0], substituteCommandLine(h.shellCommand, substitutions)})
+	case runPerFile:
+		for _, file := range files {
+			substitutions[placeholderSingleFile] = file
+			invocations = append(invocations, invocation{file, substituteCommandLine(h.shellCommand, substitutions)})
+		}
+	case runBatch:
+		substitutions[placeholderSingleFile] = []string{}
+		budget := maxCommandLineLength - commandLineLength(substituteCommandLine(h.shellCommand, substitutions))
+		for _, chunk := range chunkFiles(files, budget, countPlaceholder(h.shellCommand, placeholderSingleFile)) {
+			substitutions[placeholderSingleFile] = chunk
+			invocations = append(invocations, invocation{fmt.Sprintf("%d files", len(chunk)), substituteCommandLine(h.shellCommand, substitutions)})
 		}
+	}
+
+	deadline := newDeadline(h.timeout, ctx.DefaultTimeout)
+	defer deadline.stop()
 
-		substitutions[placeholderSingleFile] = file
-		cmd := substituteCommandLine(h.shellCommand, substitutions)
+	// Fan out individual invocations. The pool controls how many actually run concurrently.
+	errs := make([]error, len(invocations))
+	var wg sync.WaitGroup
+	for i, inv := range invocations {
+		wg.Add(1)
+		go func(i int, inv invocation) {
+			defer wg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					errs[i] = fmt.Errorf("crashed: %v", r)
+				}
+			}()
+			ctx.Workers.Do(func() {
+				// Don't start new commands once the time is up.
+				limit := deadline.start()
+				if errs[i] = limit.Err(); errs[i] != nil {
+					return
+				}
+				if h.runType == runPerCommit {
+					log.Println("Running", h.name)
+				} else {
+					log.Println("Running", h.name, "on", inv.file)
+				}
+				errs[i] = h.runCommand(limit, inv.cmd)
+			})
+		}(i, inv)
+	}
+	wg.Wait()
 
-		if h.runType == runPerCommit {
-			log.Println("Running", h.name)
-		} else if h.runType == runPerFile {
-			log.Println("Running", h.name, "on", file)
+	if deadline.passed() {
+		log.Println(h.Name(), "did not complete in time")
+		return RunTimedOut
+	}
+	for _, err := range errs {
+		if err != nil {
+			return RunFailed
 		}
+	}
 
-		runShellCommand(cmd)
-		if h.runType == runPerCommit {
-			return
+	if h.fixer {
+		return applyFixes(ctx, h.Name(), h.onFix, snapshot)
+	}
+	return RunSucceeded
+}
+
+// Run a single command, presenting its output according to the output mode.
+func (h *shellAction) runCommand(deadline context.Context, cmd []string) error {
+	switch h.output {
+	case OutputStream:
+		stdout := newPrefixWriter(os.Stdout, outputPrefix(h.Name(), os.Stdout))
+		stderr := newPrefixWriter(os.Stderr, outputPrefix(h.Name(), os.Stderr))
+		defer stdout.Flush()
+		defer stderr.Flush()
+		return runShellCommand(deadline, cmd, h.workingDir, h.env, stdout, stderr)
+
+	case OutputQuiet:
+		return runShellCommand(deadline, cmd, h.workingDir, h.env, io.Discard, io.Discard)
+
+	default:
+		var stdout, stderr bytes.Buffer
+		err := runShellCommand(deadline, cmd, h.workingDir, h.env, &stdout, &stderr)
+		if err != nil {
+			printPrefixed(os.Stdout, outputPrefix(h.Name(), os.Stdout), stdout.Bytes())
+			printPrefixed(os.Stderr, outputPrefix(h.Name(), os.Stderr), stderr.Bytes())
 		}
+		return err
 	}
 }
 
-// Return whether the hook is requested to be run.
-func (h *shellAction) IsSelected() bool {
-	return h.selected
+// Return whether the hook modifies files in place.
+func (h *shellAction) ModifiesFiles() bool {
+	return h.fixer
 }
 
 // Return whether the hook can be run.
@@ -147,22 +267,28 @@ func (h *shellAction) IsAvailable() bool {
 	return h.available
 }
 
-// Modify the selected state of the hook.
-func (h *shellAction) SetSelected(wantSelected bool) {
-	h.selected = wantSelected
+// Specify the configuration section responsible for managing the hook data.
+func (h *shellAction) SetConfig(cfg config.Config) {
+	h.actionBase.SetConfig(cfg)
+	h.setShellCmd(cfg.GetOrDefault(keyCommand, h.shellCommand[0]))
 
-	if wantSelected {
-		h.config.Set(keyEnabled, valueTrue)
+	if onFix, ok := parseFixPolicy(cfg.GetOrDefault(keyOnFix, h.onFix.String())); ok {
+		h.onFix = onFix
 	} else {
-		h.config.Remove(keyEnabled)
+		log.Println("Ignoring invalid", keyOnFix, "value for", h.Name())
 	}
-}
 
-// Specify the configuration section responsible for managing the hook data.
-func (h *shellAction) SetConfig(cfg config.Config) {
-	h.config = cfg
-	check.True(cfg != nil, "No config section")
+	if output, ok := parseOutputMode(cfg.GetOrDefault(keyOutput, h.output.String())); ok {
+		h.output = output
+	} else {
+		log.Println("Ignoring invalid", keyOutput, "value for", h.Name())
+	}
 
-	h.SetSelected(cfg.GetOrDefault(keyEnabled, "") == valueTrue)
-	h.setShellCmd(cfg.GetOrDefault(keyCommand, h.shellCommand[0]))
+	if cfg.Has(keyTimeout) {
+		if timeout, err := time.ParseDuration(cfg.GetOrDefault(keyTimeout, "")); err == nil && timeout >= 0 {
+			h.timeout = timeout
+		} else {
+			log.Println("Ignoring invalid", keyTimeout, "value for", h.Name())
+		}
+	}
 }
@@ -0,0 +1,125 @@
+package hooks
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_shellAction_timeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		cmd     []string
+		timeout time.Duration
+		// Time the only worker is kept busy before the action can run.
+		busy time.Duration
+		want RunResult
+	}{
+		{
+			name:    "Completes in time",
+			cmd:     []string{"true"},
+			timeout: time.Second,
+			want:    RunSucceeded,
+		},
+		{
+			name: "Times out",
+			// The grandchild must be terminated too, or waiting for its
+			// output keeps the action running.
+			cmd:     []string{"sh", "-c", "sleep 5 & sleep 5; wait"},
+			timeout: 100 * time.Millisecond,
+			want:    RunTimedOut,
+		},
+		{
+			name:    "Waiting for worker doesn't count",
+			cmd:     []string{"true"},
+			timeout: 100 * time.Millisecond,
+			busy:    300 * time.Millisecond,
+			want:    RunSucceeded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, _ := newFileFilter("", nil, nil)
+			action := newShellAction("id", "name", 0, FailureBlocks, filter, tt.cmd, runPerCommit, false, FixStages, tt.timeout, OutputBuffer)
+			action.SetConfig(fakeConfig{keyEnabled: valueTrue})
+			if !action.IsAvailable() {
+				t.Skipf("%s is not available", tt.cmd[0])
+			}
+
+			ctx := &RunContext{HookID: "pre-push", Files: []string{"a"}, Workers: NewWorkerPool(1)}
+			started := make(chan struct{})
+			go ctx.Workers.Do(func() {
+				close(started)
+				time.Sleep(tt.busy)
+			})
+			<-started
+
+			begin := time.Now()
+			if got := action.Run(ctx); got != tt.want {
+				t.Errorf("Run() = %v, want %v", got, tt.want)
+			}
+			if elapsed := time.Since(begin); elapsed > 3*time.Second {
+				t.Errorf("Run() took %v, commands not terminated in time", elapsed)
+			}
+		})
+	}
+}
+
+func Test_shellAction_files(t *testing.T) {
+	tests := []struct {
+		name string
+		// Script run with the command arguments, in the output directory.
+		script string
+		args   []string
+		want   string
+		// Whether the script records the name of the list of files.
+		wantList bool
+	}{
+		{
+			name:   "All files per commit",
+			script: `printf '%s\n' "$@" > out`,
+			args:   []string{placeholderAllFiles},
+			want:   "a.go\nb.go\n",
+		},
+		{
+			name:     "List of files",
+			script:   `cp "$1" out && printf '%s' "$1" > list`,
+			args:     []string{placeholderFileList},
+			want:     "a.go\nb.go\n",
+			wantList: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			cmd := append([]string{"sh", "-c", "cd " + dir + " && " + tt.script, "sh"}, tt.args...)
+			filter, _ := newFileFilter("", nil, nil)
+			action := newShellAction("id", "name", 0, FailureBlocks, filter, cmd, runPerCommit, false, FixStages, 0, OutputBuffer)
+			action.SetConfig(fakeConfig{keyEnabled: valueTrue})
+			if !action.IsAvailable() {
+				t.Skipf("%s is not available", cmd[0])
+			}
+
+			ctx := &RunContext{HookID: "pre-push", Files: []string{"a.go", "b.go"}, Workers: NewWorkerPool(1)}
+			if got := action.Run(ctx); got != RunSucceeded {
+				t.Fatalf("Run() = %v, want %v", got, RunSucceeded)
+			}
+			if got, _ := os.ReadFile(filepath.Join(dir, "out")); string(got) != tt.want {
+				t.Errorf("command received %q, want %q", got, tt.want)
+			}
+
+			if !tt.wantList {
+				return
+			}
+			// The list of files is removed once the action completes.
+			list, err := os.ReadFile(filepath.Join(dir, "list"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := os.Stat(string(list)); !os.IsNotExist(err) {
+				t.Errorf("list of files %s not removed, error = %v", list, err)
+			}
+		})
+	}
+}
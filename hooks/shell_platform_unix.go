@@ -0,0 +1,24 @@
+//go:build !windows
+
+package hooks
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// Maximum length of the command line passed to exec.
+// The actual limit (ARG_MAX) is shared with the environment and varies
+// between systems; this value leaves a generous margin on all of them.
+const maxCommandLineLength = 128 * 1024
+
+// Configure the command to start in a new process group, so that the command
+// and all its children can be terminated together.
+func startInProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// Terminate all processes in the process group of the started command.
+func killProcessGroup(cmd *exec.Cmd) {
+	syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
@@ -0,0 +1,26 @@
+//go:build windows
+
+package hooks
+
+import (
+	"fmt"
+	"os/exec"
+	"syscall"
+)
+
+// Maximum length of the command line passed to CreateProcess, leaving some
+// margin for quoting of individual arguments.
+const maxCommandLineLength = 30 * 1024
+
+// Configure the command to start in a new process group, so that the command
+// and all its children can be terminated together.
+func startInProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
+
+// Terminate the started command along with all its child processes.
+func killProcessGroup(cmd *exec.Cmd) {
+	if exec.Command("taskkill", "/T", "/F", "/PID", fmt.Sprint(cmd.Process.Pid)).Run() != nil {
+		cmd.Process.Kill()
+	}
+}
@@ -1,12 +1,17 @@
 package hooks
 
 import (
-	"bytes"
+	"context"
+	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/exec"
 	"path"
-	"strings"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/tomasz-wiszkowski/git-hooks/check"
 )
@@ -35,22 +40,40 @@ func getShellCommandAbsolutePath(executableName string) (bool, string) {
 
 // Execute supplied shell command.
 // The command must be supplied in an "exploded" form, where each argument is a
-// separate string. Returns a pair of strings: stdout and stderr.
-func runShellCommand(args []string) (stdout, stderr string) {
+// separate string. The output of the command is passed to stdout and stderr.
+// Returns an error if the command could not be started or exited with a
+// non-zero status. When the deadline expires, the command and all its child
+// processes are terminated, and the deadline error is returned.
+// The command is run in dir, or in the current directory if dir is empty, with
+// env variables added to the environment of the current process.
+func runShellCommand(deadline context.Context, args []string, dir string, env []string, stdout, stderr io.Writer) error {
 	cmd := exec.Command(args[0], args[1:]...)
-	var outb, errb bytes.Buffer
-	cmd.Stdout = &outb
-	cmd.Stderr = &errb
-	err := cmd.Run()
-	outStr := strings.TrimSpace(outb.String())
-	errStr := strings.TrimSpace(errb.String())
-	if err != nil {
-		log.Printf("Command failed")
-		log.Println(outStr)
-		log.Println(errStr)
+	cmd.Dir = dir
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	startInProcessGroup(cmd)
+
+	err := cmd.Start()
+	if err == nil {
+		done := make(chan error, 1)
+		go func() { done <- cmd.Wait() }()
+
+		select {
+		case err = <-done:
+		case <-deadline.Done():
+			killProcessGroup(cmd)
+			<-done
+			err = deadline.Err()
+		}
 	}
 
-	return outStr, errStr
+	if err != nil {
+		log.Printf("Command %s failed: %s", args[0], err)
+	}
+	return err
 }
 
 // Substitute arguments and construct a command line.
@@ -77,3 +100,168 @@ func substituteCommandLine(inputCmdLine []string, substituteArgs map[string]inte
 	}
 	return out
 }
+
+// Populate substitutions for the refs being pushed.
+// Every placeholder expands to the list of corresponding values, one for each ref.
+func addPushRefSubstitutions(substitutions map[string]interface{}, refs []PushRef) {
+	localRefs := []string{}
+	localShas := []string{}
+	remoteRefs := []string{}
+	remoteShas := []string{}
+
+	for _, ref := range refs {
+		localRefs = append(localRefs, ref.LocalRef)
+		localShas = append(localShas, ref.LocalSha)
+		remoteRefs = append(remoteRefs, ref.RemoteRef)
+		remoteShas = append(remoteShas, ref.RemoteSha)
+	}
+
+	substitutions[placeholderLocalRef] = localRefs
+	substitutions[placeholderLocalSha] = localShas
+	substitutions[placeholderRemoteRef] = remoteRefs
+	substitutions[placeholderRemoteSha] = remoteShas
+}
+
+// Compute the number of bytes the command line occupies when passed to exec.
+// Every argument is followed by a terminating NUL character.
+func commandLineLength(args []string) int {
+	length := 0
+	for _, arg := range args {
+		length += len(arg) + 1
+	}
+	return length
+}
+
+// Count the number of times the placeholder appears in the command line.
+func countPlaceholder(args []string, placeholder string) int {
+	count := 0
+	for _, arg := range args {
+		if arg == placeholder {
+			count++
+		}
+	}
+	return count
+}
+
+// Split files into chunks, such that each chunk, when substituted into the
+// command line the specified number of times, does not exceed the budget.
+// Every chunk contains at least one file, even if that file alone exceeds the
+// budget.
+func chunkFiles(files []string, budget int, repeat int) [][]string {
+	chunks := [][]string{}
+	chunk := []string{}
+	used := 0
+
+	for _, file := range files {
+		cost := (len(file) + 1) * repeat
+		if len(chunk) > 0 && used+cost > budget {
+			chunks = append(chunks, chunk)
+			chunk = []string{}
+			used = 0
+		}
+		chunk = append(chunk, file)
+		used += cost
+	}
+
+	if len(chunk) > 0 {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+// Write the supplied file names to a new temporary file, one per line.
+// Returns the name of the temporary file. The caller is responsible for
+// removing the file once it is no longer needed.
+func writeFileList(files []string) (string, error) {
+	f, err := os.CreateTemp("", "githooks-files-*.txt")
+	if err != nil {
+		return "", err
+	}
+
+	for _, file := range files {
+		if _, err = fmt.Fprintln(f, file); err != nil {
+			break
+		}
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// Translate the configured environment variables into the KEY=VALUE form,
+// sorted by name. References to variables of the current environment, eg.
+// $HOME or ${HOME}, are expanded in values.
+func buildEnvironment(vars map[string]string) []string {
+	env := []string{}
+	for name, value := range vars {
+		env = append(env, name+"="+os.ExpandEnv(value))
+	}
+	sort.Strings(env)
+	return env
+}
+
+// Express the supplied worktree-relative file names relative to dir, which is
+// itself relative to the worktree root. Files that cannot be expressed this
+// way are returned unchanged.
+func relativePaths(files []string, dir string) []string {
+	out := make([]string, 0, len(files))
+	for _, file := range files {
+		if rel, err := filepath.Rel(dir, file); err == nil {
+			file = filepath.ToSlash(rel)
+		}
+		out = append(out, file)
+	}
+	return out
+}
+
+// Time limit of an action. The limit starts once the first command of the
+// action acquires a worker, so that the time spent waiting for other actions
+// to release workers doesn't count.
+type actionDeadline struct {
+	timeout time.Duration
+	once    sync.Once
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+// Create a deadline of the supplied timeout. If the timeout is zero, the
+// fallback value is used instead. If both are zero, the deadline never
+// passes.
+func newDeadline(timeout, fallback time.Duration) *actionDeadline {
+	if timeout == 0 {
+		timeout = fallback
+	}
+	return &actionDeadline{timeout: timeout}
+}
+
+// Start the deadline, unless it's started already, and return the context
+// expiring with it.
+func (d *actionDeadline) start() context.Context {
+	d.once.Do(func() {
+		if d.timeout == 0 {
+			d.ctx, d.cancel = context.WithCancel(context.Background())
+		} else {
+			d.ctx, d.cancel = context.WithTimeout(context.Background(), d.timeout)
+		}
+	})
+	return d.ctx
+}
+
+// Check whether the deadline has passed. Deadlines never started don't pass.
+// Must not be called while commands are run.
+func (d *actionDeadline) passed() bool {
+	return d.ctx != nil && d.ctx.Err() == context.DeadlineExceeded
+}
+
+// Release resources associated with the deadline.
+// Must not be called while commands are run.
+func (d *actionDeadline) stop() {
+	if d.cancel != nil {
+		d.cancel()
+	}
+}
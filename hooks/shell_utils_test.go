@@ -1,6 +1,7 @@
 package hooks
 
 import (
+	"os"
 	"reflect"
 	"testing"
 )
@@ -81,3 +82,107 @@ func Test_substituteCommandLine(t *testing.T) {
 		})
 	}
 }
+
+func Test_chunkFiles(t *testing.T) {
+	type args struct {
+		files  []string
+		budget int
+		repeat int
+	}
+	tests := []struct {
+		name string
+		args args
+		want [][]string
+	}{
+		{
+			name: "No files",
+			args: args{[]string{}, 10, 1},
+			want: [][]string{},
+		},
+		{
+			name: "All files fit",
+			args: args{[]string{"a", "b", "c"}, 6, 1},
+			want: [][]string{{"a", "b", "c"}},
+		},
+		{
+			name: "Files split across chunks",
+			args: args{[]string{"a", "b", "c"}, 4, 1},
+			want: [][]string{{"a", "b"}, {"c"}},
+		},
+		{
+			name: "Repeated placeholder",
+			args: args{[]string{"a", "b", "c"}, 4, 2},
+			want: [][]string{{"a"}, {"b"}, {"c"}},
+		},
+		{
+			name: "File exceeding budget",
+			args: args{[]string{"a", "very-long-name", "b"}, 4, 1},
+			want: [][]string{{"a"}, {"very-long-name"}, {"b"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := chunkFiles(tt.args.files, tt.args.budget, tt.args.repeat); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("chunkFiles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_relativePaths(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		dir   string
+		want  []string
+	}{
+		{
+			name:  "Files within directory",
+			files: []string{"web/src/a.ts", "web/b.ts"},
+			dir:   "web",
+			want:  []string{"src/a.ts", "b.ts"},
+		},
+		{
+			name:  "Files outside directory",
+			files: []string{"go.mod", "api/x.go"},
+			dir:   "web/src",
+			want:  []string{"../../go.mod", "../../api/x.go"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := relativePaths(tt.files, tt.dir); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("relativePaths() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_buildEnvironment(t *testing.T) {
+	t.Setenv("GITHOOKS_TEST_HOME", "/home/user")
+
+	got := buildEnvironment(map[string]string{
+		"NODE_ENV": "test",
+		"CACHE":    "$GITHOOKS_TEST_HOME/.cache",
+	})
+	want := []string{"CACHE=/home/user/.cache", "NODE_ENV=test"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("buildEnvironment() = %v, want %v", got, want)
+	}
+}
+
+func Test_writeFileList(t *testing.T) {
+	name, err := writeFileList([]string{"a.go", "dir/b c.go"})
+	if err != nil {
+		t.Fatalf("writeFileList() error = %v", err)
+	}
+	defer os.Remove(name)
+
+	content, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "a.go\ndir/b c.go\n"; string(content) != want {
+		t.Errorf("writeFileList() wrote %q, want %q", content, want)
+	}
+}
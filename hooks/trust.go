@@ -0,0 +1,144 @@
+package hooks
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/tomasz-wiszkowski/git-hooks/repo"
+)
+
+const (
+	// Configuration section recording the approval of the repository config file.
+	trustSection    = "githooks"
+	trustSubsection = "trust"
+	// Configuration key holding the hash of the approved repository config file.
+	keyRepoConfigHash = "repoConfigHash"
+
+	// Location of the approved copy of the repository config file, relative
+	// to the repository configuration directory.
+	trustedConfigPath = "githooks/trusted.json"
+)
+
+// Approval state of the repository config file.
+type repoTrust struct {
+	repo repo.Repo
+	// Name of the repository config file, used to present the changes.
+	name string
+	// Current content of the repository config file.
+	content []byte
+	// Whether the current content is approved by the user.
+	trusted bool
+}
+
+// Approval state of the repository config file. Nil if the repository has no config file.
+var kRepoTrust *repoTrust = nil
+
+// Create a new repoTrust object, comparing the content against the approved one.
+func newRepoTrust(r repo.Repo, name string, content []byte) *repoTrust {
+	cfg := r.GetConfigManager().GetConfigFor(trustSection, trustSubsection)
+	return &repoTrust{
+		repo:    r,
+		name:    name,
+		content: content,
+		trusted: cfg.GetOrDefault(keyRepoConfigHash, "") == hashContent(content),
+	}
+}
+
+// Return the content of the repository config file as it was last approved,
+// or nil if it was never approved.
+func (t *repoTrust) approvedContent() []byte {
+	content, err := util.ReadFile(t.repo.ConfigDir(), trustedConfigPath)
+	if err != nil {
+		return nil
+	}
+	return content
+}
+
+// Record the approval of the current content, and save its copy so that
+// subsequent changes can be presented to the user.
+func (t *repoTrust) approve() error {
+	if err := util.WriteFile(t.repo.ConfigDir(), trustedConfigPath, t.content, 0644); err != nil {
+		return fmt.Errorf("unable to save approved config: %w", err)
+	}
+
+	mgr := t.repo.GetConfigManager()
+	mgr.GetConfigFor(trustSection, trustSubsection).Set(keyRepoConfigHash, hashContent(t.content))
+	mgr.Save()
+
+	t.trusted = true
+	return nil
+}
+
+// Compute the hash identifying the content.
+func hashContent(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// Check whether the repository config file is approved by the user.
+// Returns true if the repository has no config file.
+func IsRepoConfigTrusted() bool {
+	loadHooksAndSettings()
+	return kRepoTrust == nil || kRepoTrust.trusted
+}
+
+// Describe the changes made to the repository config since it was last
+// approved, in the form of a diff. The diff compares the resolved config, ie.
+// the repository config file merged with the files it includes and extends,
+// in the JSON form.
+func GetRepoConfigChanges() string {
+	loadHooksAndSettings()
+	if kRepoTrust == nil {
+		return ""
+	}
+	name := kRepoTrust.name + " (resolved)"
+	return renderDiff(name, kRepoTrust.approvedContent(), kRepoTrust.content)
+}
+
+// Approve the current content of the repository config file, permitting its
+// actions to run. The hooks and settings are reloaded upon next use, so that
+// the settings and overrides of the repository config apply too; the config
+// store must be specified for the reloaded hooks again.
+func TrustRepoConfig() error {
+	loadHooksAndSettings()
+	if kRepoTrust == nil {
+		return nil
+	}
+	if err := kRepoTrust.approve(); err != nil {
+		return err
+	}
+	kKnownHooks, kSettings = nil, nil
+	return nil
+}
+
+// Action defined in the repository config file. Refuses to run until the
+// repository config file is approved by the user.
+type repoAction struct {
+	Action
+	trust *repoTrust
+}
+
+// Return whether the hook can be run.
+func (a *repoAction) IsAvailable() bool {
+	return a.trust.trusted && a.Action.IsAvailable()
+}
+
+// Return whether the wrapped action modifies files in place.
+func (a *repoAction) ModifiesFiles() bool {
+	return modifiesFiles(a.Action)
+}
+
+// Execute the action, unless the repository config file changed since it was
+// last approved.
+func (a *repoAction) Run(ctx *RunContext) RunResult {
+	if !a.IsSelected() {
+		return RunSkipped
+	}
+	if !a.trust.trusted {
+		fmt.Println("Cannot run", a.Name(), "- repository config changed since it was last approved; run: git hooks trust")
+		return RunUnavailable
+	}
+	return a.Action.Run(ctx)
+}
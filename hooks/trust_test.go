@@ -0,0 +1,118 @@
+package hooks
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/tomasz-wiszkowski/git-hooks/config"
+)
+
+// Action recording whether it was run.
+type fakeAction struct {
+	actionBase
+	ran bool
+}
+
+func (a *fakeAction) IsAvailable() bool {
+	return true
+}
+
+func (a *fakeAction) Run(ctx *RunContext) RunResult {
+	a.ran = true
+	return RunSucceeded
+}
+
+// In-memory configuration store.
+type fakeConfig map[string]string
+
+func (c fakeConfig) Set(key, value string) {
+	c[key] = value
+}
+
+func (c fakeConfig) Has(key string) bool {
+	_, ok := c[key]
+	return ok
+}
+
+func (c fakeConfig) Remove(key string) {
+	delete(c, key)
+}
+
+func (c fakeConfig) GetOrDefault(key, dflt string) string {
+	if value, ok := c[key]; ok {
+		return value
+	}
+	return dflt
+}
+
+var _ config.Config = fakeConfig{}
+
+// In-memory configuration manager, keyed by section and subsection.
+type fakeConfigManager map[string]fakeConfig
+
+func (m fakeConfigManager) GetConfigFor(section, subsection string) config.Config {
+	key := section + "." + subsection
+	if _, ok := m[key]; !ok {
+		m[key] = fakeConfig{}
+	}
+	return m[key]
+}
+
+func (m fakeConfigManager) Save() {}
+
+var _ config.ConfigManager = fakeConfigManager{}
+
+func Test_repoAction(t *testing.T) {
+	inner := &fakeAction{actionBase: newActionBase("id", "name", 0, FailureBlocks)}
+	trust := &repoTrust{trusted: false}
+	action := &repoAction{inner, trust}
+	action.SetConfig(fakeConfig{keyEnabled: valueTrue})
+
+	if action.IsAvailable() {
+		t.Errorf("untrusted action reported as available")
+	}
+	if result := action.Run(&RunContext{}); result != RunUnavailable || inner.ran {
+		t.Errorf("untrusted action run, result = %v", result)
+	}
+
+	trust.trusted = true
+	if !action.IsAvailable() {
+		t.Errorf("trusted action reported as unavailable")
+	}
+	if result := action.Run(&RunContext{}); result != RunSucceeded || !inner.ran {
+		t.Errorf("trusted action not run, result = %v", result)
+	}
+}
+
+func Test_TrustRepoConfig(t *testing.T) {
+	home, root, configDir := t.TempDir(), t.TempDir(), t.TempDir()
+	t.Setenv("HOME", home)
+	os.WriteFile(filepath.Join(home, ".githooks.json"), []byte(`{"version": 2, "hooks": {"pre-commit": {"name": "Pre-commit", "actions": {
+		"Fmt": {"name": "Fmt", "runType": "perFile", "shellCmd": ["gofmt", "-l", "<file>"]}}}}}`), 0644)
+	os.WriteFile(filepath.Join(root, ".githooks.json"), []byte(`{"version": 2, "hooks": {"pre-commit": {"actions": {
+		"Fmt": {"name": "Repo Fmt", "runType": "perFile", "shellCmd": ["gofmt", "-l", "<file>"], "override": true}}}}}`), 0644)
+
+	kRepo = &fakeRepo{workDir: osfs.New(root), configDir: osfs.New(configDir)}
+	defer func() {
+		kRepo, kRepoTrust, kKnownHooks, kSettings = nil, nil, nil, nil
+	}()
+
+	actionName := func() string {
+		return GetHooks()["pre-commit"].Actions()[0].Name()
+	}
+	if IsRepoConfigTrusted() || actionName() != "Fmt" {
+		t.Fatalf("override of untrusted repository config applied, action = %s", actionName())
+	}
+
+	if err := TrustRepoConfig(); err != nil {
+		t.Fatalf("TrustRepoConfig() error = %v", err)
+	}
+	if !IsRepoConfigTrusted() {
+		t.Errorf("IsRepoConfigTrusted() = false after approval")
+	}
+	if got := actionName(); got != "Repo Fmt" {
+		t.Errorf("action = %s after approval, want the override Repo Fmt", got)
+	}
+}
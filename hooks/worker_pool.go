@@ -0,0 +1,30 @@
+package hooks
+
+// WorkerPool bounds the number of concurrently executed commands.
+// A nil WorkerPool executes every request immediately.
+type WorkerPool struct {
+	slots chan struct{}
+}
+
+// Create a new WorkerPool permitting up to workers concurrent executions.
+// Values lower than 1 are interpreted as 1.
+func NewWorkerPool(workers int) *WorkerPool {
+	if workers < 1 {
+		workers = 1
+	}
+	return &WorkerPool{
+		slots: make(chan struct{}, workers),
+	}
+}
+
+// Execute fn as soon as a worker becomes available. Blocks until fn completes.
+func (p *WorkerPool) Do(fn func()) {
+	if p == nil {
+		fn()
+		return
+	}
+
+	p.slots <- struct{}{}
+	defer func() { <-p.slots }()
+	fn()
+}
@@ -0,0 +1,64 @@
+package hooks
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_WorkerPool(t *testing.T) {
+	tests := []struct {
+		name    string
+		pool    *WorkerPool
+		wantMax int
+	}{
+		{
+			name:    "Bounded by workers",
+			pool:    NewWorkerPool(2),
+			wantMax: 2,
+		},
+		{
+			name:    "At least one worker",
+			pool:    NewWorkerPool(0),
+			wantMax: 1,
+		},
+		{
+			name:    "Nil pool is unbounded",
+			pool:    nil,
+			wantMax: 6,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var lock sync.Mutex
+			active, max := 0, 0
+
+			var wg sync.WaitGroup
+			for i := 0; i < 6; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					tt.pool.Do(func() {
+						lock.Lock()
+						active++
+						if active > max {
+							max = active
+						}
+						lock.Unlock()
+
+						time.Sleep(20 * time.Millisecond)
+
+						lock.Lock()
+						active--
+						lock.Unlock()
+					})
+				}()
+			}
+			wg.Wait()
+
+			if max != tt.wantMax {
+				t.Errorf("max concurrent executions = %d, want %d", max, tt.wantMax)
+			}
+		})
+	}
+}
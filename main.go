@@ -1,11 +1,14 @@
 package main
 
 import (
+	"bufio"
+	"fmt"
 	"log"
 	"os"
 	"path"
 	"path/filepath"
 	"sort"
+	"strings"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/go-git/go-billy/v5/osfs"
@@ -18,50 +21,156 @@ import (
 
 func openRepo() repo.Repo {
 	r := repo.OpenRepo()
-	hooks.GetHooks().SetConfigStore(r.GetConfigManager())
+	hooks.SetRepo(r)
 	return r
 }
 
 func main() {
 	log.Default().SetFlags(log.Ltime | log.Lshortfile)
 
+	// Migration doesn't require a repository.
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		migrate(os.Args[2:])
+		return
+	}
+	// Validation reports problems that would otherwise prevent the hooks from
+	// being loaded, and doesn't require a repository either.
+	if len(os.Args) > 1 && os.Args[1] == "validate" {
+		validate()
+		return
+	}
+
+	r := openRepo()
+
+	// The repository config file is loaded along with the hooks.
+	hooks.GetHooks().SetConfigStore(r.GetConfigManager())
 	selfName := path.Base(os.Args[0])
 	hks := hooks.GetHooks()
 
 	if h, ok := hks[selfName]; ok {
-		runHooks(h, os.Args[1:])
+		runHooks(r, h, os.Args[1:])
 	} else if len(os.Args) == 1 {
-		showConfig()
+		showConfig(r)
 	} else if h, ok := hks[os.Args[1]]; ok {
-		runHooks(h, os.Args[2:])
+		runHooks(r, h, os.Args[2:])
 	} else if os.Args[1] == "install" {
-		install()
+		install(r)
+	} else if os.Args[1] == "trust" {
+		trust()
 	} else {
 		log.Fatalln("Unknown hook type", os.Args[1])
 	}
 }
 
-func runHooks(hook hooks.Hook, args []string) {
-	repo := openRepo()
-	files := repo.GetListOfNewAndModifiedFiles()
+// Select the set of files relevant to the specific hook.
+func getFilesForHook(r repo.Repo, hookType *hooks.HookType, refs []hooks.PushRef) []string {
+	switch hookType.Files {
+	case hooks.FileSetStaged:
+		return r.GetListOfStagedFiles()
+	case hooks.FileSetPushed:
+		return getFilesForPush(r, refs)
+	default:
+		return r.GetListOfNewAndModifiedFiles()
+	}
+}
+
+// Collect the union of files changed by all the refs being pushed.
+func getFilesForPush(r repo.Repo, refs []hooks.PushRef) []string {
+	files := []string{}
+	seen := map[string]bool{}
+
+	for _, ref := range refs {
+		if ref.IsDelete() {
+			continue
+		}
+		for _, file := range r.GetListOfPushedFiles(ref.LocalSha, ref.RemoteSha) {
+			if !seen[file] {
+				seen[file] = true
+				files = append(files, file)
+			}
+		}
+	}
+
+	return files
+}
+
+func runHooks(r repo.Repo, hook hooks.Hook, args []string) {
+	ctx := &hooks.RunContext{
+		HookID: hook.ID(),
+		Repo:   r,
+		Args:   args,
+	}
+
+	hookType := hooks.GetHookType(hook.ID())
+	if hookType.Stdin == hooks.StdinPushRefs {
+		refs, err := hooks.ParsePushRefs(os.Stdin)
+		check.Err(err, "Run: cannot read refs being pushed")
+		ctx.PushRefs = refs
+	}
+	ctx.Files = getFilesForHook(r, hookType, ctx.PushRefs)
 
 	// Used by hooks install, file fixing and others
-	err := os.Chdir(repo.WorkDir().Root())
+	err := os.Chdir(r.WorkDir().Root())
 	check.Err(err, "Run: cannot open work directory")
 
+	ctx.Workers = hooks.NewWorkerPool(hooks.GetSettings().Workers)
+	ctx.DefaultTimeout = hooks.GetSettings().DefaultTimeout
+
 	actions := hook.Actions()
 	sort.Slice(actions, func(a, b int) bool { return actions[a].Priority() < actions[b].Priority() })
-	for _, h := range actions {
-		h.Run(files, args)
+
+	failed := false
+	var results []hooks.RunResult
+	func() {
+		// Make sure actions only see the changes that are about to be committed.
+		if hook.ID() == "pre-commit" && hooks.GetSettings().StashUnstaged {
+			stash, err := r.StashUnstagedChanges()
+			check.Err(err, "Run: cannot stash unstaged changes")
+			if stash != nil {
+				log.Println("Run: unstaged changes saved in", stash.Location())
+				// Restore the changes even if running the actions panics.
+				defer func() {
+					if err := stash.Restore(); err != nil {
+						log.Println("Run:", err)
+						failed = true
+					}
+				}()
+			}
+		}
+
+		results = hooks.RunActions(actions, ctx)
+	}()
+
+	if hooks.SummarizeResults(os.Stdout, actions, results) {
+		failed = true
 	}
-}
 
-func showConfig() {
-	repo := openRepo()
+	// Non-zero exit status allows hooks like pre-commit to reject the operation.
+	if failed {
+		log.Fatalln("Hook", hook.ID(), "failed")
+	}
+}
 
+func showConfig(r repo.Repo) {
 	app := tview.NewApplication()
-	tree := ui.NewHookTreeView(hooks.GetHooks())
-	app.SetRoot(tree, true).EnableMouse(true)
+	showTree := func() {
+		app.SetRoot(ui.NewHookConfigView(hooks.GetHooks()), true)
+	}
+
+	// Ask the user to approve the repository config first, if it changed.
+	if hooks.IsRepoConfigTrusted() {
+		showTree()
+	} else {
+		app.SetRoot(ui.NewTrustView(hooks.GetRepoConfigChanges(), func(trusted bool) {
+			if trusted {
+				err := hooks.TrustRepoConfig()
+				check.Err(err, "Trust: failed to record approval")
+				// The hooks are reloaded with the approved repository config.
+				hooks.GetHooks().SetConfigStore(r.GetConfigManager())
+			}
+			showTree()
+		}), true)
+	}
 	app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 		if event.Key() == tcell.KeyEscape {
 			app.Stop()
@@ -72,15 +181,14 @@ func showConfig() {
 	app.EnableMouse(true)
 	err := app.Run()
 	check.Err(err, "Run: terminated abnormally")
-	repo.GetConfigManager().Save()
+	r.GetConfigManager().Save()
 }
 
-func install() {
+func install(r repo.Repo) {
 	selfAbsolutePath, err := filepath.Abs(os.Args[0])
 	check.Err(err, "Install: cannot locate self")
 
-	repo := openRepo()
-	configDir := repo.ConfigDir()
+	configDir := r.ConfigDir()
 
 	err = configDir.MkdirAll("hooks", 0755)
 	check.Err(err, "Install: failed to create hooks directory")
@@ -101,5 +209,64 @@ func install() {
 		check.Err(err, "Install: failed to install hook %s", hook.Name())
 	}
 
-	showConfig()
+	showConfig(r)
+}
+
+// Present the changes made to the repository config file since it was last
+// approved, and ask the user to approve them.
+func trust() {
+	if hooks.IsRepoConfigTrusted() {
+		fmt.Println("Repository config is trusted")
+		return
+	}
+
+	fmt.Print(hooks.GetRepoConfigChanges())
+	fmt.Print("Trust the repository config? [y/N] ")
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	if strings.ToLower(strings.TrimSpace(answer)) != "y" {
+		fmt.Println("Repository config not trusted")
+		return
+	}
+
+	err := hooks.TrustRepoConfig()
+	check.Err(err, "Trust: failed to record approval")
+	fmt.Println("Repository config trusted")
+}
+
+// Rewrite the version 1 config file, by default the user config file, as a
+// version 2 config file.
+func migrate(args []string) {
+	var name string
+	if len(args) > 0 {
+		name = args[0]
+	} else {
+		var err error
+		name, err = hooks.FindUserConfigFile()
+		check.Err(err, "Migrate: cannot locate user config file")
+		check.True(len(name) > 0, "Migrate: no user config file found")
+	}
+
+	err := hooks.MigrateConfigFile(name)
+	check.Err(err, "Migrate: failed to migrate %s", name)
+	fmt.Println("Migrated", name, "- original content saved in", name+".v1")
+}
+
+// Report all problems found in the user and repository config files. Only the
+// user config file is validated outside of a repository. Exits with a
+// non-zero status if any problems are found.
+func validate() {
+	if r, err := repo.TryOpenRepo(); err == nil {
+		hooks.SetRepo(r)
+	} else {
+		fmt.Println("Not in a repository, validating the user config only")
+	}
+
+	problems := hooks.ValidateConfig()
+	for _, problem := range problems {
+		fmt.Fprintln(os.Stderr, problem)
+	}
+	if len(problems) > 0 {
+		log.Fatalln("Validate:", len(problems), "problem(s) found")
+	}
+	fmt.Println("Config is valid")
 }
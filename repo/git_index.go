@@ -0,0 +1,171 @@
+package repo
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+)
+
+// Environment variable naming the index file git uses instead of the default
+// one, eg. while `git commit -a` or `git commit <path>` runs the hooks.
+const envIndexFile = "GIT_INDEX_FILE"
+
+// Return the absolute path of the index file named by GIT_INDEX_FILE, or an
+// empty string if the variable is not set. Relative paths are resolved
+// against the current directory, ie. the directory git runs the hooks in.
+func getIndexFileFromEnv() (string, error) {
+	name := os.Getenv(envIndexFile)
+	if len(name) == 0 {
+		return "", nil
+	}
+	return filepath.Abs(name)
+}
+
+// Read the index. The index named by GIT_INDEX_FILE takes precedence over
+// the default one. The caller must hold the repository lock.
+func (g *gitRepo) loadIndex() (*index.Index, error) {
+	if len(g.indexFile) == 0 {
+		return g.repo.Storer.Index()
+	}
+
+	idx := &index.Index{Version: 2}
+	f, err := os.Open(g.indexFile)
+	if os.IsNotExist(err) {
+		return idx, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	err = index.NewDecoder(bufio.NewReader(f)).Decode(idx)
+	return idx, err
+}
+
+// Write the index. The index named by GIT_INDEX_FILE takes precedence over
+// the default one. The caller must hold the repository lock.
+func (g *gitRepo) writeIndex(idx *index.Index) error {
+	if len(g.indexFile) == 0 {
+		return g.repo.Storer.SetIndex(idx)
+	}
+
+	f, err := os.Create(g.indexFile)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	err = index.NewEncoder(w).Encode(idx)
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Store the content as a blob object, and return its hash.
+func (g *gitRepo) writeBlob(content []byte) (plumbing.Hash, error) {
+	obj := g.repo.Storer.NewEncodedObject()
+	obj.SetType(plumbing.BlobObject)
+	obj.SetSize(int64(len(content)))
+
+	w, err := obj.Writer()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	if _, err = w.Write(content); err != nil {
+		w.Close()
+		return plumbing.ZeroHash, err
+	}
+	if err = w.Close(); err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	return g.repo.Storer.SetEncodedObject(obj)
+}
+
+// Read the content and mode of the file in the working directory. Symbolic
+// links are represented by their target, as in the index.
+func (g *gitRepo) readWorkFile(path string) ([]byte, filemode.FileMode, os.FileInfo, error) {
+	workDir := g.WorkDir()
+	info, err := workDir.Lstat(path)
+	if err != nil {
+		return nil, filemode.Empty, nil, err
+	}
+
+	var content []byte
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := workDir.Readlink(path)
+		if err != nil {
+			return nil, filemode.Empty, nil, err
+		}
+		content = []byte(target)
+	} else if content, err = util.ReadFile(workDir, path); err != nil {
+		return nil, filemode.Empty, nil, err
+	}
+
+	mode, err := filemode.NewFromOSFileMode(info.Mode())
+	return content, mode, info, err
+}
+
+// Check whether the file in the working directory differs from the index
+// entry. Files missing from the working directory are considered modified.
+func (g *gitRepo) isModified(e *index.Entry) (bool, error) {
+	content, mode, _, err := g.readWorkFile(e.Name)
+	if os.IsNotExist(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return mode != e.Mode || plumbing.ComputeHash(plumbing.BlobObject, content) != e.Hash, nil
+}
+
+// Update the index entry of the file to reflect its content in the working
+// directory. Files missing from the working directory are removed from the
+// index.
+func (g *gitRepo) stageFile(idx *index.Index, path string) error {
+	content, mode, info, err := g.readWorkFile(path)
+	if os.IsNotExist(err) {
+		_, err = idx.Remove(path)
+		if err == index.ErrEntryNotFound {
+			err = nil
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	hash, err := g.writeBlob(content)
+	if err != nil {
+		return err
+	}
+
+	e, err := idx.Entry(path)
+	if err == index.ErrEntryNotFound {
+		e = idx.Add(path)
+	} else if err != nil {
+		return err
+	}
+	e.Hash = hash
+	e.Mode = mode
+	e.Size = uint32(info.Size())
+	e.ModifiedAt = info.ModTime()
+	return nil
+}
+
+// Sort index entries by name, as required by the index format.
+func sortIndexEntries(idx *index.Index) {
+	sort.SliceStable(idx.Entries, func(a, b int) bool {
+		return idx.Entries[a].Name < idx.Entries[b].Name
+	})
+}
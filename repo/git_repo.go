@@ -2,11 +2,17 @@ package repo
 
 import (
 	"fmt"
+	"io"
 	"log"
+	"strings"
+	"sync"
 
 	billy "github.com/go-git/go-billy/v5"
 	"github.com/go-git/go-git/v5"
 	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-git/go-git/v5/storage/filesystem"
 	"github.com/go-git/go-git/v5/utils/merkletrie"
@@ -17,19 +23,35 @@ import (
 type gitRepo struct {
 	repo   *git.Repository
 	config *gitconfig.Config
+	// Index file named by GIT_INDEX_FILE. Empty means the default index.
+	indexFile string
+	// Serializes all access to the repository objects, references and index.
+	// go-git caches decoded objects and pack indexes without synchronization,
+	// and actions of the same priority query the repository concurrently.
+	lock sync.Mutex
 }
 
-func gitRepoOpen() Repo {
+func gitRepoOpen() (Repo, error) {
 	r, err := git.PlainOpenWithOptions(".", &git.PlainOpenOptions{DetectDotGit: true})
-	check.Err(err, "Git: cannot open repository")
+	if err != nil {
+		return nil, fmt.Errorf("cannot open repository: %w", err)
+	}
 
 	c, err := r.Config()
-	check.Err(err, "Git: cannot query repository config")
+	if err != nil {
+		return nil, fmt.Errorf("cannot query repository config: %w", err)
+	}
 
-	return &gitRepo{
-		repo:   r,
-		config: c,
+	indexFile, err := getIndexFileFromEnv()
+	if err != nil {
+		return nil, fmt.Errorf("cannot locate index file: %w", err)
 	}
+
+	return &gitRepo{
+		repo:      r,
+		config:    c,
+		indexFile: indexFile,
+	}, nil
 }
 
 func (g *gitRepo) WorkDir() billy.Filesystem {
@@ -51,13 +73,26 @@ func (g *gitRepo) GetConfigManager() config.ConfigManager {
 	}
 }
 
-func (g *gitRepo) GetListOfAllFiles() []string {
+// Query the top-most commit and collect the list of modified files.
+func (g *gitRepo) GetListOfNewAndModifiedFiles() []string {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
 	head, err := g.repo.Head()
 	check.Err(err, "Git: Can't Query HEAD")
 
 	commit, err := g.repo.CommitObject(head.Hash())
-	check.Err(err, "Git: Can't get top commit")
+	check.Err(err, "Git: Can't Get top commit")
 
+	if commit.NumParents() == 0 {
+		log.Println("Unable to query parent commit - assuming first commit")
+	}
+
+	return getListOfFilesChangedBy(commit)
+}
+
+// Collect the list of all files in the supplied commit.
+func getListOfFilesIn(commit *object.Commit) []string {
 	tree, err := commit.Tree()
 	check.Err(err, "Git: Can't get commit tree")
 
@@ -69,22 +104,15 @@ func (g *gitRepo) GetListOfAllFiles() []string {
 		paths = append(paths, f.Name)
 		return nil
 	})
-	fmt.Println(paths)
 	return paths
 }
 
-/// Query the top-most commit and collect the list of modified files.
-func (g *gitRepo) GetListOfNewAndModifiedFiles() []string {
-	head, err := g.repo.Head()
-	check.Err(err, "Git: Can't Query HEAD")
-
-	commit, err := g.repo.CommitObject(head.Hash())
-	check.Err(err, "Git: Can't Get top commit")
-
+// Collect the list of files added or modified by the supplied commit, compared
+// to its first parent. All files are reported for commits without parents.
+func getListOfFilesChangedBy(commit *object.Commit) []string {
 	parent, err := commit.Parent(0)
 	if err != nil {
-		log.Println("Unable to query parent commit - assuming first commit")
-		return g.GetListOfAllFiles()
+		return getListOfFilesIn(commit)
 	}
 
 	tree1, err := commit.Tree()
@@ -114,3 +142,323 @@ func (g *gitRepo) GetListOfNewAndModifiedFiles() []string {
 
 	return paths
 }
+
+// Collect the list of files added or modified by commits that would be sent
+// to the remote when updating the remote ref from remoteSha to localSha.
+func (g *gitRepo) GetListOfPushedFiles(localSha, remoteSha string) []string {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	var paths []string
+	seen := map[string]bool{}
+	g.forEachPushedCommit(localSha, remoteSha, func(c *object.Commit) {
+		for _, path := range getListOfFilesChangedBy(c) {
+			if !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+	})
+
+	return paths
+}
+
+func (g *gitRepo) GetListOfPushedCommits(localSha, remoteSha string) []Commit {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	var commits []Commit
+	g.forEachPushedCommit(localSha, remoteSha, func(c *object.Commit) {
+		subject := strings.SplitN(c.Message, "\n", 2)[0]
+		commits = append(commits, Commit{Sha: c.Hash.String(), Subject: subject})
+	})
+
+	return commits
+}
+
+// Visit every commit that would be sent to the remote when updating the
+// remote ref from remoteSha to localSha.
+// If the remote ref does not exist (or its commit is not known locally), the
+// commits already present in any of the remote-tracking refs are excluded.
+// Deleting the remote ref, ie. pushing the zero localSha, sends no commits.
+func (g *gitRepo) forEachPushedCommit(localSha, remoteSha string, visit func(*object.Commit)) {
+	if plumbing.NewHash(localSha).IsZero() {
+		return
+	}
+
+	local, err := g.repo.CommitObject(plumbing.NewHash(localSha))
+	check.Err(err, "Git: Can't find pushed commit %s", localSha)
+
+	// Collect all commits already known to the remote.
+	known := map[plumbing.Hash]bool{}
+	boundaries := []plumbing.Hash{}
+	if remote := plumbing.NewHash(remoteSha); !remote.IsZero() && g.hasCommit(remote) {
+		boundaries = append(boundaries, remote)
+	} else {
+		refs, err := g.repo.References()
+		check.Err(err, "Git: Can't list references")
+
+		err = refs.ForEach(func(r *plumbing.Reference) error {
+			if r.Type() == plumbing.HashReference && r.Name().IsRemote() {
+				boundaries = append(boundaries, r.Hash())
+			}
+			return nil
+		})
+		check.Err(err, "Git: Can't list references")
+	}
+
+	for _, b := range boundaries {
+		commit, err := g.repo.CommitObject(b)
+		if err != nil {
+			continue
+		}
+		err = object.NewCommitPreorderIter(commit, known, nil).ForEach(func(c *object.Commit) error {
+			known[c.Hash] = true
+			return nil
+		})
+		check.Err(err, "Git: Can't walk history of %s", b)
+	}
+
+	err = object.NewCommitPreorderIter(local, known, nil).ForEach(func(c *object.Commit) error {
+		visit(c)
+		return nil
+	})
+	check.Err(err, "Git: Can't walk history of %s", localSha)
+}
+
+// Check whether the remote ref can be updated from remoteSha to localSha
+// without discarding any commits. Returns false if the commit remoteSha is
+// not available locally.
+func (g *gitRepo) IsFastForward(remoteSha, localSha string) (bool, error) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	remote, err := g.repo.CommitObject(plumbing.NewHash(remoteSha))
+	if err == plumbing.ErrObjectNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	local, err := g.repo.CommitObject(plumbing.NewHash(localSha))
+	if err != nil {
+		return false, err
+	}
+
+	return remote.IsAncestor(local)
+}
+
+// Check whether the commit is available in the local repository.
+func (g *gitRepo) hasCommit(hash plumbing.Hash) bool {
+	_, err := g.repo.CommitObject(hash)
+	return err == nil
+}
+
+// Compare the index against the HEAD commit and collect the list of added and
+// modified files. When HEAD does not exist yet (ie. the initial commit is being
+// created), all files in the index are reported.
+func (g *gitRepo) GetListOfStagedFiles() []string {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	idx, err := g.loadIndex()
+	check.Err(err, "Git: Can't read index")
+
+	type headEntry struct {
+		hash plumbing.Hash
+		mode filemode.FileMode
+	}
+	headFiles := map[string]headEntry{}
+
+	head, err := g.repo.Head()
+	if err == nil {
+		commit, err := g.repo.CommitObject(head.Hash())
+		check.Err(err, "Git: Can't Get top commit")
+
+		tree, err := commit.Tree()
+		check.Err(err, "Git: Can't Get current tree")
+
+		err = tree.Files().ForEach(func(f *object.File) error {
+			headFiles[f.Name] = headEntry{f.Hash, f.Mode}
+			return nil
+		})
+		check.Err(err, "Git: Can't list files in current tree")
+	} else if err != plumbing.ErrReferenceNotFound {
+		check.Err(err, "Git: Can't Query HEAD")
+	}
+
+	var paths []string
+	for _, e := range idx.Entries {
+		// Unmerged entries are reported by git itself; commit can't proceed with these.
+		// Note: index.Merged is declared as 1, but merged entries are decoded with stage 0.
+		if e.Stage != 0 {
+			continue
+		}
+		if h, ok := headFiles[e.Name]; ok && h.hash == e.Hash && h.mode == e.Mode {
+			continue
+		}
+		paths = append(paths, e.Name)
+	}
+
+	return paths
+}
+
+func (g *gitRepo) StageFiles(paths []string) error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	idx, err := g.loadIndex()
+	if err != nil {
+		return err
+	}
+
+	// Note: Worktree.Add computes the status of the entire worktree for every
+	// file, hence the index is updated directly.
+	for _, path := range paths {
+		if err = g.stageFile(idx, path); err != nil {
+			return fmt.Errorf("unable to stage %s: %w", path, err)
+		}
+	}
+	sortIndexEntries(idx)
+	return g.writeIndex(idx)
+}
+
+func (g *gitRepo) GetUnstagedFiles(paths []string) ([]string, error) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	idx, err := g.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	unstaged := []string{}
+	for _, path := range paths {
+		e, err := idx.Entry(path)
+		if err == index.ErrEntryNotFound {
+			unstaged = append(unstaged, path)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		modified, err := g.isModified(e)
+		if err != nil {
+			return nil, fmt.Errorf("unable to inspect %s: %w", path, err)
+		}
+		if modified {
+			unstaged = append(unstaged, path)
+		}
+	}
+	return unstaged, nil
+}
+
+func (g *gitRepo) CurrentBranch() (string, error) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	// Note: Head() fails if the branch has no commits yet, hence the symbolic ref is read directly.
+	head, err := g.repo.Storer.Reference(plumbing.HEAD)
+	if err != nil {
+		return "", err
+	}
+	if head.Type() != plumbing.SymbolicReference || !head.Target().IsBranch() {
+		return "", fmt.Errorf("HEAD is detached")
+	}
+	return head.Target().Short(), nil
+}
+
+func (g *gitRepo) GetStagedBlobs(paths []string, sniffLength int) (map[string]StagedBlob, error) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	idx, err := g.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	blobs := map[string]StagedBlob{}
+	for _, path := range paths {
+		e, err := idx.Entry(path)
+		if err == index.ErrEntryNotFound || (err == nil && e.Mode == filemode.Submodule) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		blob, err := g.repo.BlobObject(e.Hash)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read staged %s: %w", path, err)
+		}
+
+		reader, err := blob.Reader()
+		if err != nil {
+			return nil, fmt.Errorf("unable to read staged %s: %w", path, err)
+		}
+		head := make([]byte, sniffLength)
+		n, err := io.ReadFull(reader, head)
+		reader.Close()
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return nil, fmt.Errorf("unable to read staged %s: %w", path, err)
+		}
+
+		blobs[path] = StagedBlob{Size: blob.Size, Head: head[:n]}
+	}
+	return blobs, nil
+}
+
+func (g *gitRepo) GetStagedContents(paths []string) (map[string]StagedContent, error) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	idx, err := g.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	var tree *object.Tree
+	head, err := g.repo.Head()
+	if err == nil {
+		commit, err := g.repo.CommitObject(head.Hash())
+		if err != nil {
+			return nil, err
+		}
+		if tree, err = commit.Tree(); err != nil {
+			return nil, err
+		}
+	} else if err != plumbing.ErrReferenceNotFound {
+		return nil, err
+	}
+
+	contents := map[string]StagedContent{}
+	for _, path := range paths {
+		e, err := idx.Entry(path)
+		if err == index.ErrEntryNotFound || (err == nil && e.Mode == filemode.Submodule) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var content StagedContent
+		if content.Current, err = g.readBlob(e.Hash); err != nil {
+			return nil, fmt.Errorf("unable to read staged %s: %w", path, err)
+		}
+
+		if tree != nil {
+			if f, err := tree.File(path); err == nil {
+				if content.Previous, err = g.readBlob(f.Hash); err != nil {
+					return nil, fmt.Errorf("unable to read committed %s: %w", path, err)
+				}
+			} else if err != object.ErrFileNotFound {
+				return nil, err
+			}
+		}
+
+		contents[path] = content
+	}
+	return contents, nil
+}
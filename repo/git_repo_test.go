@@ -0,0 +1,431 @@
+package repo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Create a new repository in a temporary directory.
+func newTestRepo(t *testing.T) (*gitRepo, string) {
+	dir := t.TempDir()
+	r, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := r.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &gitRepo{repo: r, config: c}, dir
+}
+
+// Write the file in the working directory of the repository.
+func writeTestFile(t *testing.T, dir, name, content string, perm os.FileMode) {
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), perm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, perm); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Add the files to the default index.
+func addTestFiles(t *testing.T, g *gitRepo, names ...string) {
+	wt, err := g.repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range names {
+		if _, err := wt.Add(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// Commit the content of the default index.
+func commitTestFiles(t *testing.T, g *gitRepo) {
+	wt, err := g.repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = wt.Commit("test", &git.CommitOptions{
+		Author: &object.Signature{Name: "Test", Email: "test@example.com", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Write the file, and commit it with the message. Returns the new commit.
+func commitTestChange(t *testing.T, g *gitRepo, dir, name, message string) plumbing.Hash {
+	writeTestFile(t, dir, name, message, 0644)
+	addTestFiles(t, g, name)
+	wt, err := g.repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := wt.Commit(message, &git.CommitOptions{
+		Author: &object.Signature{Name: "Test", Email: "test@example.com", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
+
+// Point the remote-tracking ref at the commit.
+func setTestRemoteRef(t *testing.T, g *gitRepo, name string, hash plumbing.Hash) {
+	ref := plumbing.NewHashReference(plumbing.ReferenceName("refs/remotes/"+name), hash)
+	if err := g.repo.Storer.SetReference(ref); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Return the hash of the index entry, or the zero hash if the file is not
+// present in the index.
+func getIndexedHash(t *testing.T, g *gitRepo, name string) plumbing.Hash {
+	idx, err := g.loadIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := idx.Entry(name)
+	if err != nil {
+		return plumbing.ZeroHash
+	}
+	return e.Hash
+}
+
+func Test_gitRepo_GetListOfStagedFiles(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(t *testing.T, g *gitRepo, dir string)
+		want  []string
+	}{
+		{
+			name: "Initial commit",
+			setup: func(t *testing.T, g *gitRepo, dir string) {
+				writeTestFile(t, dir, "a.txt", "a", 0644)
+				writeTestFile(t, dir, "d/b.txt", "b", 0644)
+				addTestFiles(t, g, "a.txt", "d/b.txt")
+			},
+			want: []string{"a.txt", "d/b.txt"},
+		},
+		{
+			name: "Unchanged files excluded",
+			setup: func(t *testing.T, g *gitRepo, dir string) {
+				writeTestFile(t, dir, "a.txt", "a", 0644)
+				writeTestFile(t, dir, "b.txt", "b", 0644)
+				addTestFiles(t, g, "a.txt", "b.txt")
+				commitTestFiles(t, g)
+
+				writeTestFile(t, dir, "b.txt", "b2", 0644)
+				writeTestFile(t, dir, "c.txt", "c", 0644)
+				addTestFiles(t, g, "b.txt", "c.txt")
+			},
+			want: []string{"b.txt", "c.txt"},
+		},
+		{
+			name: "Mode change",
+			setup: func(t *testing.T, g *gitRepo, dir string) {
+				writeTestFile(t, dir, "run.sh", "true", 0644)
+				writeTestFile(t, dir, "a.txt", "a", 0644)
+				addTestFiles(t, g, "run.sh", "a.txt")
+				commitTestFiles(t, g)
+
+				writeTestFile(t, dir, "run.sh", "true", 0755)
+				addTestFiles(t, g, "run.sh")
+			},
+			want: []string{"run.sh"},
+		},
+		{
+			name: "Unstaged changes ignored",
+			setup: func(t *testing.T, g *gitRepo, dir string) {
+				writeTestFile(t, dir, "a.txt", "a", 0644)
+				addTestFiles(t, g, "a.txt")
+				commitTestFiles(t, g)
+
+				writeTestFile(t, dir, "a.txt", "a2", 0644)
+			},
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, dir := newTestRepo(t)
+			tt.setup(t, g, dir)
+
+			got := g.GetListOfStagedFiles()
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetListOfStagedFiles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_gitRepo_StageFiles(t *testing.T) {
+	tests := []struct {
+		name      string
+		indexFile string
+	}{
+		{
+			name: "Default index",
+		},
+		{
+			name:      "Index named by GIT_INDEX_FILE",
+			indexFile: "next-index",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, dir := newTestRepo(t)
+			writeTestFile(t, dir, "a.txt", "a", 0644)
+			writeTestFile(t, dir, "b.txt", "b", 0644)
+			addTestFiles(t, g, "a.txt", "b.txt")
+			commitTestFiles(t, g)
+			committed := getIndexedHash(t, g, "a.txt")
+
+			if len(tt.indexFile) > 0 {
+				// Start with the copy of the default index, as git does.
+				content, err := os.ReadFile(filepath.Join(dir, ".git", "index"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				g.indexFile = filepath.Join(dir, ".git", tt.indexFile)
+				if err := os.WriteFile(g.indexFile, content, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			writeTestFile(t, dir, "a.txt", "a2", 0644)
+			writeTestFile(t, dir, "c.txt", "c", 0644)
+			if err := os.Remove(filepath.Join(dir, "b.txt")); err != nil {
+				t.Fatal(err)
+			}
+			if err := g.StageFiles([]string{"a.txt", "b.txt", "c.txt"}); err != nil {
+				t.Fatalf("StageFiles() failed: %v", err)
+			}
+
+			// Re-stage the file modified once again.
+			writeTestFile(t, dir, "a.txt", "a3", 0644)
+			if err := g.StageFiles([]string{"a.txt"}); err != nil {
+				t.Fatalf("StageFiles() failed: %v", err)
+			}
+
+			if got, want := getIndexedHash(t, g, "a.txt"), plumbing.ComputeHash(plumbing.BlobObject, []byte("a3")); got != want {
+				t.Errorf("a.txt staged as %v, want %v", got, want)
+			}
+			if got := getIndexedHash(t, g, "b.txt"); !got.IsZero() {
+				t.Errorf("b.txt still staged as %v", got)
+			}
+			if got, want := getIndexedHash(t, g, "c.txt"), plumbing.ComputeHash(plumbing.BlobObject, []byte("c")); got != want {
+				t.Errorf("c.txt staged as %v, want %v", got, want)
+			}
+			if got, want := g.GetListOfStagedFiles(), []string{"a.txt", "c.txt"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("GetListOfStagedFiles() = %v, want %v", got, want)
+			}
+			if _, err := g.repo.BlobObject(plumbing.ComputeHash(plumbing.BlobObject, []byte("a3"))); err != nil {
+				t.Errorf("staged content not stored: %v", err)
+			}
+
+			if len(tt.indexFile) > 0 {
+				// Default index must remain intact.
+				g.indexFile = ""
+				if got := getIndexedHash(t, g, "a.txt"); got != committed {
+					t.Errorf("default index modified, a.txt staged as %v, want %v", got, committed)
+				}
+			}
+		})
+	}
+}
+
+func Test_gitRepo_GetUnstagedFiles(t *testing.T) {
+	g, dir := newTestRepo(t)
+	writeTestFile(t, dir, "clean.txt", "a", 0644)
+	writeTestFile(t, dir, "modified.txt", "b", 0644)
+	writeTestFile(t, dir, "mode.sh", "c", 0644)
+	writeTestFile(t, dir, "deleted.txt", "d", 0644)
+	addTestFiles(t, g, "clean.txt", "modified.txt", "mode.sh", "deleted.txt")
+
+	writeTestFile(t, dir, "modified.txt", "b2", 0644)
+	writeTestFile(t, dir, "mode.sh", "c", 0755)
+	writeTestFile(t, dir, "untracked.txt", "e", 0644)
+	if err := os.Remove(filepath.Join(dir, "deleted.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := g.GetUnstagedFiles([]string{"clean.txt", "deleted.txt", "mode.sh", "modified.txt", "untracked.txt"})
+	if err != nil {
+		t.Fatalf("GetUnstagedFiles() failed: %v", err)
+	}
+	if want := []string{"deleted.txt", "mode.sh", "modified.txt", "untracked.txt"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetUnstagedFiles() = %v, want %v", got, want)
+	}
+}
+
+func Test_gitRepo_concurrentStagedReads(t *testing.T) {
+	g, dir := newTestRepo(t)
+	var names []string
+	for i := 0; i < 16; i++ {
+		name := fmt.Sprintf("file%d.txt", i)
+		writeTestFile(t, dir, name, name, 0644)
+		names = append(names, name)
+	}
+	addTestFiles(t, g, names...)
+	commitTestFiles(t, g)
+	// Packed objects go through the pack index caches, which are not safe
+	// for concurrent use.
+	if err := g.repo.RepackObjects(&git.RepackConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range names {
+		writeTestFile(t, dir, name, name+" modified", 0644)
+	}
+	addTestFiles(t, g, names...)
+
+	r, err := git.PlainOpen(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := r.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	g = &gitRepo{repo: r, config: c}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			contents, err := g.GetStagedContents(names)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			for _, name := range names {
+				if got := string(contents[name].Previous); got != name {
+					t.Errorf("GetStagedContents() %s = %q, want %q", name, got, name)
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			blobs, err := g.GetStagedBlobs(names, 4)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			for _, name := range names {
+				if got := blobs[name].Size; got != int64(len(name+" modified")) {
+					t.Errorf("GetStagedBlobs() %s size = %d, want %d", name, got, len(name+" modified"))
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func Test_gitRepo_GetListOfPushedFiles(t *testing.T) {
+	g, dir := newTestRepo(t)
+	first := commitTestChange(t, g, dir, "a.txt", "first")
+	second := commitTestChange(t, g, dir, "b.txt", "second")
+	third := commitTestChange(t, g, dir, "c.txt", "third")
+	setTestRemoteRef(t, g, "origin/main", first)
+
+	tests := []struct {
+		name      string
+		localSha  string
+		remoteSha string
+		want      []string
+	}{
+		{
+			name:      "Remote ref updated",
+			localSha:  third.String(),
+			remoteSha: second.String(),
+			want:      []string{"c.txt"},
+		},
+		{
+			name:      "New branch",
+			localSha:  third.String(),
+			remoteSha: plumbing.ZeroHash.String(),
+			want:      []string{"b.txt", "c.txt"},
+		},
+		{
+			name:      "Remote sha unknown locally",
+			localSha:  third.String(),
+			remoteSha: "1234567890123456789012345678901234567890",
+			want:      []string{"b.txt", "c.txt"},
+		},
+		{
+			name:      "Remote ref deleted",
+			localSha:  plumbing.ZeroHash.String(),
+			remoteSha: third.String(),
+			want:      []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := append([]string{}, g.GetListOfPushedFiles(tt.localSha, tt.remoteSha)...)
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetListOfPushedFiles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_gitRepo_IsFastForward(t *testing.T) {
+	g, dir := newTestRepo(t)
+	first := commitTestChange(t, g, dir, "a.txt", "first")
+	second := commitTestChange(t, g, dir, "b.txt", "second")
+
+	tests := []struct {
+		name      string
+		remoteSha string
+		localSha  string
+		want      bool
+	}{
+		{"Fast-forward", first.String(), second.String(), true},
+		{"Non-fast-forward", second.String(), first.String(), false},
+		{"Remote sha unknown locally", "1234567890123456789012345678901234567890", second.String(), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := g.IsFastForward(tt.remoteSha, tt.localSha)
+			if err != nil {
+				t.Fatalf("IsFastForward() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("IsFastForward() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_gitRepo_GetListOfPushedCommits(t *testing.T) {
+	g, dir := newTestRepo(t)
+	first := commitTestChange(t, g, dir, "a.txt", "first")
+	second := commitTestChange(t, g, dir, "b.txt", "WIP: second\n\nbody")
+	third := commitTestChange(t, g, dir, "c.txt", "third")
+
+	got := g.GetListOfPushedCommits(third.String(), first.String())
+	want := []Commit{{Sha: third.String(), Subject: "third"}, {Sha: second.String(), Subject: "WIP: second"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetListOfPushedCommits() = %v, want %v", got, want)
+	}
+}
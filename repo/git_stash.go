@@ -0,0 +1,193 @@
+package repo
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	billy "github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+)
+
+// Location of saved modifications, relative to the repository configuration directory.
+const stashDir = "githooks/stash"
+
+// Single file with unstaged modifications.
+type stashedFile struct {
+	// Path relative to the working directory root.
+	path string
+	// Staged content, checked out into the working directory.
+	staged []byte
+	// Whether the file was deleted from the working directory.
+	deleted bool
+}
+
+// Unstaged modifications, saved aside while the working directory reflects the index.
+type gitStash struct {
+	workDir   billy.Filesystem
+	configDir billy.Filesystem
+	// Directory holding copies of the modified files.
+	backup billy.Filesystem
+	// Path of the backup directory, relative to the configuration directory.
+	backupPath string
+	files      []stashedFile
+}
+
+func (g *gitRepo) StashUnstagedChanges() (Stash, error) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	idx, err := g.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	workDir := g.WorkDir()
+	backupPath := path.Join(stashDir, fmt.Sprint(time.Now().UnixNano()))
+	stash := &gitStash{
+		workDir:    workDir,
+		configDir:  g.ConfigDir(),
+		backupPath: backupPath,
+	}
+
+	for _, e := range idx.Entries {
+		// Note: index.Merged is declared as 1, but merged entries are decoded with stage 0.
+		if e.Stage != 0 || (e.Mode != filemode.Regular && e.Mode != filemode.Executable) {
+			continue
+		}
+		if err = stash.stashFile(g, e); err != nil {
+			return nil, stash.rollback(err)
+		}
+	}
+
+	if len(stash.files) == 0 {
+		return nil, nil
+	}
+	return stash, nil
+}
+
+// Save unstaged modifications of the file aside, if there are any, and check
+// out its staged content.
+func (s *gitStash) stashFile(g *gitRepo, e *index.Entry) error {
+	current, err := util.ReadFile(s.workDir, e.Name)
+	deleted := os.IsNotExist(err)
+	if err != nil && !deleted {
+		return err
+	}
+	if !deleted && plumbing.ComputeHash(plumbing.BlobObject, current) == e.Hash {
+		return nil
+	}
+
+	staged, err := g.readBlob(e.Hash)
+	if err != nil {
+		return fmt.Errorf("unable to read staged %s: %w", e.Name, err)
+	}
+
+	if s.backup == nil {
+		if s.backup, err = s.configDir.Chroot(s.backupPath); err != nil {
+			return err
+		}
+	}
+	if !deleted {
+		if err = util.WriteFile(s.backup, e.Name, current, 0644); err != nil {
+			return fmt.Errorf("unable to save %s: %w", e.Name, err)
+		}
+	}
+
+	// Recorded before the file is checked out, so that a partially written
+	// file is rolled back too.
+	s.files = append(s.files, stashedFile{e.Name, staged, deleted})
+	mode, _ := e.Mode.ToOSFileMode()
+	if err = util.WriteFile(s.workDir, e.Name, staged, mode); err != nil {
+		return fmt.Errorf("unable to check out staged %s: %w", e.Name, err)
+	}
+	return nil
+}
+
+// Restore the saved modifications of all files checked out so far, after
+// stashing failed with the supplied error. Returns the error to report.
+func (s *gitStash) rollback(cause error) error {
+	failed := []string{}
+	for _, file := range s.files {
+		if err := s.restoreFile(file); err != nil {
+			failed = append(failed, file.path)
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%w; unable to restore unstaged changes to %s, saved copies are available in %s",
+			cause, strings.Join(failed, ", "), s.Location())
+	}
+	if err := util.RemoveAll(s.configDir, s.backupPath); err != nil {
+		return fmt.Errorf("%w; unable to remove saved copies from %s: %v", cause, s.Location(), err)
+	}
+	return cause
+}
+
+// Read the content of the blob object. The caller must hold the repository lock.
+func (g *gitRepo) readBlob(hash plumbing.Hash) ([]byte, error) {
+	blob, err := g.repo.BlobObject(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := blob.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return io.ReadAll(reader)
+}
+
+func (s *gitStash) Restore() error {
+	conflicts := []string{}
+
+	for _, file := range s.files {
+		current, err := util.ReadFile(s.workDir, file.path)
+		if err != nil || !bytes.Equal(current, file.staged) {
+			// Modified while the hooks were run, eg. by a fixer.
+			conflicts = append(conflicts, file.path)
+			continue
+		}
+
+		if err = s.restoreFile(file); err != nil {
+			conflicts = append(conflicts, file.path)
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return fmt.Errorf("unable to restore unstaged changes to %s; saved copies are available in %s",
+			strings.Join(conflicts, ", "), s.Location())
+	}
+
+	return util.RemoveAll(s.configDir, s.backupPath)
+}
+
+// Replace the file in the working directory with its saved copy.
+func (s *gitStash) restoreFile(file stashedFile) error {
+	if file.deleted {
+		err := s.workDir.Remove(file.path)
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return err
+	}
+
+	saved, err := util.ReadFile(s.backup, file.path)
+	if err != nil {
+		return err
+	}
+	return util.WriteFile(s.workDir, file.path, saved, 0644)
+}
+
+func (s *gitStash) Location() string {
+	return path.Join(".git", s.backupPath)
+}
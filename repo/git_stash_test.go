@@ -0,0 +1,134 @@
+package repo
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Return the content of the file in the working directory, or "<missing>" if
+// the file does not exist.
+func readTestFile(t *testing.T, dir, name string) string {
+	content, err := os.ReadFile(filepath.Join(dir, name))
+	if os.IsNotExist(err) {
+		return "<missing>"
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+// Check whether the saved copies of the stash are present.
+func hasSavedCopies(t *testing.T, dir string, stash Stash) bool {
+	_, err := os.Stat(filepath.Join(dir, stash.Location()))
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	return err == nil
+}
+
+// Create a repository with the staged files, and modify the working
+// directory copies of some of them.
+func newTestStashRepo(t *testing.T) (*gitRepo, string) {
+	g, dir := newTestRepo(t)
+	writeTestFile(t, dir, "clean.txt", "clean", 0644)
+	writeTestFile(t, dir, "modified.txt", "staged", 0644)
+	writeTestFile(t, dir, "deleted.txt", "deleted", 0644)
+	addTestFiles(t, g, "clean.txt", "modified.txt", "deleted.txt")
+
+	writeTestFile(t, dir, "modified.txt", "unstaged", 0644)
+	if err := os.Remove(filepath.Join(dir, "deleted.txt")); err != nil {
+		t.Fatal(err)
+	}
+	return g, dir
+}
+
+func Test_gitRepo_StashUnstagedChanges(t *testing.T) {
+	g, dir := newTestStashRepo(t)
+
+	stash, err := g.StashUnstagedChanges()
+	if err != nil || stash == nil {
+		t.Fatalf("StashUnstagedChanges() = %v, %v", stash, err)
+	}
+	for name, want := range map[string]string{"clean.txt": "clean", "modified.txt": "staged", "deleted.txt": "deleted"} {
+		if got := readTestFile(t, dir, name); got != want {
+			t.Errorf("stashed %s = %q, want %q", name, got, want)
+		}
+	}
+	if !hasSavedCopies(t, dir, stash) {
+		t.Errorf("saved copies missing from %s", stash.Location())
+	}
+
+	if err := stash.Restore(); err != nil {
+		t.Fatalf("Restore() failed: %v", err)
+	}
+	for name, want := range map[string]string{"clean.txt": "clean", "modified.txt": "unstaged", "deleted.txt": "<missing>"} {
+		if got := readTestFile(t, dir, name); got != want {
+			t.Errorf("restored %s = %q, want %q", name, got, want)
+		}
+	}
+	if hasSavedCopies(t, dir, stash) {
+		t.Errorf("saved copies retained in %s", stash.Location())
+	}
+}
+
+func Test_gitRepo_StashUnstagedChanges_noChanges(t *testing.T) {
+	g, dir := newTestRepo(t)
+	writeTestFile(t, dir, "clean.txt", "clean", 0644)
+	addTestFiles(t, g, "clean.txt")
+
+	if stash, err := g.StashUnstagedChanges(); stash != nil || err != nil {
+		t.Errorf("StashUnstagedChanges() = %v, %v, want nil", stash, err)
+	}
+}
+
+func Test_gitStash_Restore_conflict(t *testing.T) {
+	g, dir := newTestStashRepo(t)
+
+	stash, err := g.StashUnstagedChanges()
+	if err != nil || stash == nil {
+		t.Fatalf("StashUnstagedChanges() = %v, %v", stash, err)
+	}
+	writeTestFile(t, dir, "modified.txt", "fixed", 0644)
+
+	err = stash.Restore()
+	if err == nil || !strings.Contains(err.Error(), "modified.txt") || !strings.Contains(err.Error(), stash.Location()) {
+		t.Errorf("Restore() = %v, want conflict reported", err)
+	}
+	if got := readTestFile(t, dir, "modified.txt"); got != "fixed" {
+		t.Errorf("conflicting modified.txt = %q, want %q", got, "fixed")
+	}
+	if got := readTestFile(t, dir, "deleted.txt"); got != "<missing>" {
+		t.Errorf("restored deleted.txt = %q, want %q", got, "<missing>")
+	}
+	if !hasSavedCopies(t, dir, stash) {
+		t.Errorf("saved copies removed from %s", stash.Location())
+	}
+}
+
+func Test_gitRepo_StashUnstagedChanges_rollback(t *testing.T) {
+	g, dir := newTestRepo(t)
+	writeTestFile(t, dir, "a.txt", "staged", 0644)
+	writeTestFile(t, dir, "d/x.txt", "staged", 0644)
+	addTestFiles(t, g, "a.txt", "d/x.txt")
+
+	// The file a.txt is stashed first; d/x.txt can't be read afterwards, as
+	// d is no longer a directory.
+	writeTestFile(t, dir, "a.txt", "unstaged", 0644)
+	if err := os.RemoveAll(filepath.Join(dir, "d")); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, dir, "d", "file", 0644)
+
+	if stash, err := g.StashUnstagedChanges(); stash != nil || err == nil {
+		t.Fatalf("StashUnstagedChanges() = %v, %v, want failure", stash, err)
+	}
+	if got := readTestFile(t, dir, "a.txt"); got != "unstaged" {
+		t.Errorf("a.txt = %q after failed stash, want %q", got, "unstaged")
+	}
+	if entries, err := os.ReadDir(filepath.Join(dir, ".git", stashDir)); err == nil && len(entries) > 0 {
+		t.Errorf("saved copies retained after failed stash: %v", entries)
+	}
+}
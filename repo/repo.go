@@ -13,19 +13,97 @@ type Repo interface {
 	WorkDir() billy.Filesystem
 	// Return absolute path to repository configuration directory.
 	ConfigDir() billy.Filesystem
-	// Return a list of all modified and added files, relative to
-	// the working directory root.
+	// Return a list of all files modified and added by the most recent
+	// commit, relative to the working directory root.
 	GetListOfNewAndModifiedFiles() []string
+	// Return a list of all files added or modified in the index (ie. staged
+	// for the next commit), relative to the working directory root.
+	// Methods accessing the index use the index named by GIT_INDEX_FILE, if
+	// set, eg. while git runs the hooks for `git commit -a`.
+	GetListOfStagedFiles() []string
+	// Return a list of all files added or modified by commits that are
+	// about to be pushed, when the remote ref is updated from remoteSha to
+	// localSha. The remoteSha may be all zeros, if the remote ref is new.
+	GetListOfPushedFiles(localSha, remoteSha string) []string
+	// Return a list of commits that are about to be pushed, when the remote
+	// ref is updated from remoteSha to localSha, newest first.
+	GetListOfPushedCommits(localSha, remoteSha string) []Commit
+	// Check whether updating the remote ref from remoteSha to localSha is
+	// a fast-forward, ie. remoteSha is an ancestor of localSha.
+	IsFastForward(remoteSha, localSha string) (bool, error)
+	// Return the size and up to sniffLength leading bytes of the staged
+	// content of the supplied files, relative to the working directory root.
+	// Files not present in the index, and submodules, are omitted.
+	GetStagedBlobs(paths []string, sniffLength int) (map[string]StagedBlob, error)
+	// Return the content of the supplied files, relative to the working
+	// directory root, in the HEAD commit and in the index. Files not present
+	// in the index, and submodules, are omitted.
+	GetStagedContents(paths []string) (map[string]StagedContent, error)
+	// Return the short name of the currently checked out branch, eg. main.
+	// Returns an error if no branch is checked out (ie. HEAD is detached).
+	CurrentBranch() (string, error)
+	// Add the current content of the supplied files, relative to the working
+	// directory root, to the index.
+	StageFiles(paths []string) error
+	// Return the supplied files, relative to the working directory root,
+	// whose content in the working directory differs from the index, ie.
+	// files with unstaged modifications. Files not present in the index are
+	// reported as well.
+	GetUnstagedFiles(paths []string) ([]string, error)
+	// Save unstaged modifications of tracked files aside, and replace them with
+	// their staged content. Returns nil if there are no unstaged modifications.
+	// On failure, the modifications of files replaced so far are restored.
+	StashUnstagedChanges() (Stash, error)
 	// Create (if required) and return the configuration manager that
 	// can be used to persist configuration for the current repo.
 	GetConfigManager() config.ConfigManager
 }
 
+// Summary of a single commit.
+type Commit struct {
+	// Full hash of the commit.
+	Sha string
+	// First line of the commit message.
+	Subject string
+}
+
+// Staged content of a single file.
+type StagedBlob struct {
+	// Size of the content, in bytes.
+	Size int64
+	// Leading bytes of the content.
+	Head []byte
+}
+
+// Content of a single file before and after the staged modifications.
+type StagedContent struct {
+	// Content in the HEAD commit. Nil if the file is new.
+	Previous []byte
+	// Content in the index.
+	Current []byte
+}
+
+// Unstaged modifications of the working directory, saved aside.
+type Stash interface {
+	// Restore the saved modifications. Files modified since the modifications
+	// were saved are not restored, and their saved copies are retained.
+	Restore() error
+	// Return the location of the saved copies of the modified files,
+	// relative to the working directory root.
+	Location() string
+}
+
 // Attempt to identify and open repository under current path.
 func OpenRepo() Repo {
-	if maybeGit := gitRepoOpen(); maybeGit != nil {
-		return maybeGit
+	r, err := TryOpenRepo()
+	if err != nil {
+		log.Fatalln("Git:", err)
 	}
-	log.Fatalf("Unable to determine repository type")
-	return nil
+	return r
+}
+
+// Attempt to identify and open repository under current path. Returns an
+// error if the current path is not within a repository.
+func TryOpenRepo() (Repo, error) {
+	return gitRepoOpen()
 }
@@ -0,0 +1,33 @@
+package ui
+
+import (
+	"github.com/rivo/tview"
+	"github.com/tomasz-wiszkowski/git-hooks/hooks"
+)
+
+// TUI element presenting the HooksTreeView along with the description of the
+// currently highlighted action.
+type HookConfigView struct {
+	*tview.Flex
+}
+
+// Instantiate a new HookConfigView TUI element, populated with all known hooks
+// and actions.
+func NewHookConfigView(data hooks.Hooks) *HookConfigView {
+	tree := NewHookTreeView(data)
+	details := tview.NewTextView().SetWordWrap(true)
+	details.SetBorder(true).SetTitle(" Description ")
+
+	tree.SetChangedFunc(func(node *tview.TreeNode) {
+		details.SetText("")
+		if ref, ok := node.GetReference().(*hookTreeNodeData); ok && ref.action != nil {
+			details.SetText(ref.hook.ActionInfo(ref.action.ID()).Description)
+		}
+	})
+
+	flex := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(tree, 0, 1, true).
+		AddItem(details, 5, 0, false)
+
+	return &HookConfigView{flex}
+}
@@ -36,7 +36,7 @@ func (v *HooksTreeView) addActionTreeNodes(target *tview.TreeNode, ref *hookTree
 
 	for _, h := range actions {
 		node := tview.NewTreeNode("").SetReference(&hookTreeNodeData{ref.hook, h}).SetSelectable(true)
-		v.updateTreeNode(h, node)
+		v.updateTreeNode(ref.hook, h, node)
 		target.AddChild(node)
 	}
 }
@@ -51,8 +51,9 @@ func (v *HooksTreeView) add(target *tview.TreeNode, ref *hookTreeNodeData) {
 	}
 }
 
-// Update the tree node's display text.
-func (v *HooksTreeView) updateTreeNode(action hooks.Action, node *tview.TreeNode) {
+// Update the tree node's display text, noting the config file the action is
+// defined in and the tags the action is classified with.
+func (v *HooksTreeView) updateTreeNode(hook hooks.Hook, action hooks.Action, node *tview.TreeNode) {
 	var marker rune
 	if !action.IsSelected() {
 		marker = ' '
@@ -62,7 +63,17 @@ func (v *HooksTreeView) updateTreeNode(action hooks.Action, node *tview.TreeNode
 		marker = '✔'
 	}
 
-	node.SetText(fmt.Sprintf("[%c] %s", marker, action.Name()))
+	info := hook.ActionInfo(action.ID())
+	source := info.Source.String()
+	if info.Source == hooks.SourceRepo && !hooks.IsRepoConfigTrusted() {
+		source += ", untrusted"
+	}
+
+	text := fmt.Sprintf("[%c] %s (%s)", marker, action.Name(), source)
+	for _, tag := range info.Tags {
+		text += " #" + tag
+	}
+	node.SetText(text)
 }
 
 // Respond to user selection. Toggle expanded state of nodes, and
@@ -77,7 +88,7 @@ func (v *HooksTreeView) onTreeNodeSelected(node *tview.TreeNode) {
 	} else {
 		// Leaf (ie. action): toggle enabled state.
 		action.SetSelected(!action.IsSelected())
-		v.updateTreeNode(action, node)
+		v.updateTreeNode(reference.hook, action, node)
 	}
 }
 
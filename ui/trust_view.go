@@ -0,0 +1,28 @@
+package ui
+
+import (
+	"github.com/rivo/tview"
+)
+
+// TUI element presenting changes made to the repository config file, and
+// asking the user to approve them.
+type TrustView struct {
+	*tview.Flex
+}
+
+// Instantiate a new TrustView TUI element. The onDone function is called with
+// the user's decision.
+func NewTrustView(changes string, onDone func(trusted bool)) *TrustView {
+	text := tview.NewTextView().SetText(changes).SetScrollable(true)
+	text.SetBorder(true).SetTitle(" Repository config changed since it was last approved ")
+
+	buttons := tview.NewForm().
+		AddButton("Trust", func() { onDone(true) }).
+		AddButton("Don't trust", func() { onDone(false) })
+
+	flex := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(text, 0, 1, false).
+		AddItem(buttons, 3, 0, true)
+
+	return &TrustView{flex}
+}